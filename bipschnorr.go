@@ -4,6 +4,9 @@ package bipschnorr
 
 import (
 	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
 	"math/big"
 )
 
@@ -26,23 +29,21 @@ type Point [2]*big.Int
 
 // NewPoint retuns a point for private key.
 func NewPoint(d *big.Int) *Point {
-	return pointMul(d, G)
+	var k scalar
+	return scalarBaseMul(k.setInt(d))
 }
 
-// NewPointForPub retuns a point for public key.
+// NewPointForPub retuns a point for public key, a 33 byte compressed encoding,
+// or nil if the encoding is invalid or not on the curve. ParsePoint returns the reason as an error.
 func NewPointForPub(pub []byte) *Point {
-	if len(pub) != 33 || (pub[0] != 0x02 && pub[0] != 0x03) {
+	if len(pub) != 33 {
 		return nil
 	}
-	x := new(big.Int).SetBytes(pub[1:])
-	y := new(big.Int).ModSqrt(add(exp(x, big.NewInt(3)), big.NewInt(7)), p)
-	if y == nil {
-		return &Point{}
-	}
-	if (pub[0] == 0x02 && y.Bit(0) == 1) || (pub[0] == 0x03 && y.Bit(0) == 0) {
-		y = mod(sub(p, y), p)
+	P, err := ParsePoint(pub)
+	if err != nil {
+		return nil
 	}
-	return &Point{x, y}
+	return P
 }
 
 // Bytes returns compress point bytes.
@@ -52,12 +53,65 @@ func (p *Point) Bytes() []byte {
 	return ll(bs, bytes(x(p)))
 }
 
+// ParsePoint parses a public key in any SEC1 form, compressed (33 bytes), uncompressed or hybrid (65 bytes),
+// or as a BIP340 x-only key (32 bytes, even Y). Unlike NewPointForPub it returns an error for invalid input.
+func ParsePoint(pub []byte) (*Point, error) {
+	switch len(pub) {
+	case 32:
+		return parseX(pub, 0)
+	case 33:
+		if pub[0] != 0x02 && pub[0] != 0x03 {
+			return nil, fmt.Errorf("invalid prefix 0x%02x for a compressed point", pub[0])
+		}
+		return parseX(pub[1:], uint(pub[0]&1))
+	case 65:
+		if pub[0] != 0x04 && pub[0] != 0x06 && pub[0] != 0x07 {
+			return nil, fmt.Errorf("invalid prefix 0x%02x for an uncompressed point", pub[0])
+		}
+		P := &Point{intbs(pub[1:33]), intbs(pub[33:65])}
+		if x(P).Cmp(p) >= 0 || y(P).Cmp(p) >= 0 {
+			return nil, fmt.Errorf("coordinate not below p")
+		}
+		if !oncurve(P) {
+			return nil, ErrPubKeyNotOnCurve
+		}
+		// A hybrid prefix also carries the parity of y.
+		if pub[0] != 0x04 && uint(pub[0]&1) != y(P).Bit(0) {
+			return nil, fmt.Errorf("hybrid prefix 0x%02x does not match y", pub[0])
+		}
+		return P, nil
+	}
+	return nil, fmt.Errorf("invalid point length %d", len(pub))
+}
+
+// parseX returns the point with X coordinate int(bs) and y mod 2 = odd.
+func parseX(bs []byte, odd uint) (*Point, error) {
+	if intbs(bs).Cmp(p) >= 0 {
+		return nil, fmt.Errorf("coordinate not below p")
+	}
+	P := liftX(intbs(bs))
+	if P == nil {
+		return nil, ErrPubKeyNotOnCurve
+	}
+	if odd == 1 {
+		P[1] = sub(p, y(P))
+	}
+	return P, nil
+}
+
+// BytesUncompressed returns the uncompressed point bytes, byte(0x04) || bytes(x(P)) || bytes(y(P)).
+func (p *Point) BytesUncompressed() []byte {
+	return ll([]byte{0x04}, bytes(x(p)), bytes(y(p)))
+}
+
+// BytesXOnly returns the BIP340 x-only point bytes, bytes(x(P)).
+func (p *Point) BytesXOnly() []byte {
+	return bytes(x(p))
+}
+
 // infinite(P) returns whether or not P is the point at infinity.
 func infinite(P *Point) bool {
-	if y(P) == nil || y(P).Cmp(big.NewInt(0)) == 0 {
-		return true
-	}
-	return false
+	return P.IsInfinity()
 }
 
 // x(P) and y(P) refer to the X and Y coordinates of a point P (assuming it is not infinity).
@@ -83,53 +137,23 @@ var G = &Point{
 
 // oncurve(P) returns whether a point P is on the curve and not infinite.
 func oncurve(P *Point) bool {
-	if infinite(P) {
-		return false
-	}
-	// y^2 - x^3 = 7
-	return new(big.Int).Mod(new(big.Int).Sub(
-		new(big.Int).Exp(y(P), big.NewInt(2), p),
-		new(big.Int).Exp(x(P), big.NewInt(3), p)),
-		p).Cmp(big.NewInt(7)) == 0
+	return P.IsOnCurve()
 }
 
 // Addition of points refers to the usual elliptic curve group operation.
 func pointAdd(p1, p2 *Point) *Point {
-	if infinite(p1) {
-		return p2
-	}
-	if infinite(p2) {
-		return p1
-	}
-	if (x(p1).Cmp(x(p2)) == 0) && (y(p1).Cmp(y(p2)) != 0) {
-		return &Point{}
-	}
-	var lam *big.Int
-	if (x(p1).Cmp(x(p2)) == 0) && (y(p1).Cmp(y(p2)) == 0) {
-		// 3 * x1 * x1 * (2 * y1)^(p - 2) mod p
-		lam = mod(mul(big.NewInt(3), x(p1), x(p1),
-			exp(mul(big.NewInt(2), p1[1]), sub(p, big.NewInt(2)))),
-			p)
-	} else {
-		// (y2 - y1) * (x2 - x1)^(p - 2) mod p
-		lam = mod(mul(sub(y(p2), y(p1)),
-			exp(sub(x(p2), x(p1)), sub(p, big.NewInt(2)))),
-			p)
-	}
-	x3 := mod(sub(sub(mul(lam, lam), p1[0]), p2[0]), p)
-	return &Point{x3, mod(sub(mul(lam, sub(p1[0], x3)), p1[1]), p)}
+	return new(Point).Add(p1, p2)
 }
 
 // Multiplication of an integer and a point refers to the repeated application of the group operation.
 func pointMul(x *big.Int, p *Point) *Point {
-	r := &Point{}
-	for i := 0; i < x.BitLen(); i++ {
-		if x.Bit(i) == 1 {
-			r = pointAdd(r, p)
-		}
-		p = pointAdd(p, p)
-	}
-	return r
+	return new(Point).ScalarMult(NewScalar(x), p)
+}
+
+// scalarBaseMul returns kG.
+func scalarBaseMul(k *scalar) *Point {
+	J := gBase.mul(k)
+	return J.point()
 }
 
 // Functions and operations:
@@ -173,73 +197,188 @@ func hash(x []byte) []byte {
 
 // The function jacobi(x), where x is an integer, returns the Jacobi symbol of x / p. It is equal to x(p-1)/2 mod p (Euler's criterion)
 func jacobi(x *big.Int) *big.Int {
-	z, _ := new(big.Int).QuoRem(new(big.Int).Sub(p, big.NewInt(1)), big.NewInt(2), big.NewInt(0))
-	return exp(x, z)
+	var f fieldVal
+	switch f.setInt(x).jacobi() {
+	case 1:
+		return big.NewInt(1)
+	case -1:
+		return sub(p, big.NewInt(1))
+	}
+	return big.NewInt(0)
 }
 
+// Errors returned by Verify, one for each way a signature can fail.
+var (
+	ErrMessageLength    = errors.New("message must be 32 bytes")
+	ErrSigLength        = errors.New("signature must be 64 bytes")
+	ErrPubKeyNotOnCurve = errors.New("public key not on the curve")
+	ErrSigROutOfRange   = errors.New("signature r not below p")
+	ErrSigSOutOfRange   = errors.New("signature s not below n")
+	ErrRInfinite        = errors.New("R is infinite")
+	ErrRJacobi          = errors.New("jacobi(y(R)) is not 1")
+	ErrRMismatch        = errors.New("x(R) does not match r")
+)
+
+// Errors returned by signing. A signature that fails verification after signing means a fault in the computation,
+// and emitting it could leak the secret key.
+var (
+	ErrNonceZero    = errors.New("nonce is zero")
+	ErrSigningFault = errors.New("produced signature fails verification")
+)
+
 // Verification is
 // Input:
 // The public key P: a point
 // The message m: a 32 byte array
 // A signature sig: a 64 byte array
 func Verification(P *Point, m []byte, sig []byte) bool {
+	return Verify(P, m, sig) == nil
+}
+
+// Verify is Verification returning the reason a signature is rejected, one of the Err variables above.
+func Verify(P *Point, m []byte, sig []byte) error {
 	if len(m) != 32 {
-		return false
+		return ErrMessageLength
 	}
 	if len(sig) != 64 {
-		return false
+		return ErrSigLength
 	}
 	// The signature is valid if and only if the algorithm below does not fail.
 	// Fail if not oncurve(P).
 	if !oncurve(P) {
-		return false
+		return ErrPubKeyNotOnCurve
 	}
 	// Let r = int(sig[0:32]); fail if r ≥ p.
 	r := intbs(sig[0:32])
 	if r.Cmp(p) >= 0 {
-		return false
+		return ErrSigROutOfRange
 	}
 	// Let s = int(sig[32:64]); fail if s ≥ n.
 	s := intbs(sig[32:64])
 	if s.Cmp(n) >= 0 {
-		return false
+		return ErrSigSOutOfRange
 	}
 	// Let e = int(hash(bytes(r) || bytes(P) || m)) mod n.
 	e := intbs(hash(ll(bytes(r), P.Bytes(), m)))
 	// Let R = sG - eP.
-	R := pointAdd(pointMul(s, G), pointMul(mod(sub(n, e), n), P))
+	var ks scalar
+	J := ecmult(ks.setInt(s), []ecmultTerm{term(mod(sub(n, e), n), P)})
+	R := J.point()
 	// Fail if infinite(R) or jacobi(y(R)) ≠ 1 or x(R) ≠ r.
-	if infinite(R) || jacobi(y(R)).Cmp(big.NewInt(1)) != 0 || x(R).Cmp(r) != 0 {
-		return false
+	if infinite(R) {
+		return ErrRInfinite
 	}
-	return true
+	if jacobi(y(R)).Cmp(big.NewInt(1)) != 0 {
+		return ErrRJacobi
+	}
+	if x(R).Cmp(r) != 0 {
+		return ErrRMismatch
+	}
+	return nil
 }
 
 // Signing is
 // Input:
 // The secret key d: an integer in the range 1..n-1.
 // The message m: an array of 32 bytes
+// Signing returns nil if k = 0 or the produced signature fails verification.
 func Signing(d *big.Int, m []byte) []byte {
-	if d.Cmp(n) >= 0 {
+	return SigningWithNonce(d, m, DraftNonce{})
+}
+
+// SigningWithAux is Signing with the nonce masked by auxiliary random data, as BIP340 does.
+// Input:
+// The secret key d: an integer in the range 1..n-1.
+// The message m: an array of 32 bytes
+// Auxiliary random data a: an array of 32 bytes; all zero gives the same signature as Signing
+func SigningWithAux(d *big.Int, m []byte, a []byte) []byte {
+	if len(a) != 32 {
 		return nil
 	}
-	if len(m) != 32 {
+	return SigningWithNonce(d, m, auxNonce(a))
+}
+
+// SigningWithNonce is Signing with the nonce derived by nf instead of the draft's nonce function.
+func SigningWithNonce(d *big.Int, m []byte, nf NonceFunc) []byte {
+	sig, err := signing(d, m, nf)
+	if err != nil {
 		return nil
 	}
-	// To sign:
-	// Let k = int(hash(bytes(d) || m)) mod n.
+	return sig
+}
 
-	k := mod(intbs(hash(ll(bytes(d), m))), n)
+// signing returns the signature of m with the secret key d and the nonce function nf,
+// verifying it before it is returned.
+func signing(d *big.Int, m []byte, nf NonceFunc) ([]byte, error) {
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("secret key out of range 1..n-1")
+	}
+	var sd scalar
+	// Wipe the copy of the secret key on return.
+	defer func() {
+		sd = scalar{}
+	}()
+	sd.setInt(d)
+	return signScalar(&sd, m, nf)
+}
+
+// signScalar is signing with the secret key held as a scalar, so no big.Int copy of it is made.
+func signScalar(sd *scalar, m []byte, nf NonceFunc) ([]byte, error) {
+	if sd.isZero() {
+		return nil, fmt.Errorf("secret key out of range 1..n-1")
+	}
+	if len(m) != 32 {
+		return nil, ErrMessageLength
+	}
+	// To sign:
+	// Let k = int(nonce(bytes(d), m)) mod n, where the draft's nonce is hash(bytes(d) || m); fail if k = 0.
+	var k, e scalar
+	db := sd.bytes()
+	// Wipe the nonce and bytes(d) on return.
+	defer func() {
+		k = scalar{}
+		for i := range db {
+			db[i] = 0
+		}
+	}()
+	k.setBytes(nf.Nonce(db, m))
+	if k.isZero() {
+		return nil, ErrNonceZero
+	}
 	// Let R = kG.
-	R := pointMul(k, G)
+	R := scalarBaseMul(&k)
 	// If jacobi(y(R)) ≠ 1, let k = n - k.
 	if jacobi(y(R)).Cmp(big.NewInt(1)) != 0 {
-		k = sub(n, k)
+		k.neg(&k)
 	}
 	// Let e = int(hash(bytes(x(R)) || bytes(dG) || m)) mod n.
-	e := mod(intbs(hash(ll(bytes(x(R)), pointMul(d, G).Bytes(), m))), n)
+	P := scalarBaseMul(sd)
+	e.setBytes(hash(ll(bytes(x(R)), P.Bytes(), m)))
 	// The signature is bytes(x(R)) || bytes(k + ed mod n).
-	return ll(bytes(x(R)), bytes(mod(add(k, mul(e, d)), n)))
+	sig := ll(bytes(x(R)), e.mul(&e, sd).add(&e, &k).bytes())
+	// Fail if Verification(dG, m, sig) fails.
+	if !Verification(P, m, sig) {
+		return nil, ErrSigningFault
+	}
+	return sig, nil
+}
+
+// SigningWithRand is SigningWithAux with 32 bytes of auxiliary random data read from rand.
+func SigningWithRand(d *big.Int, m []byte, rand io.Reader) ([]byte, error) {
+	a, err := readAux(rand)
+	if err != nil {
+		return nil, err
+	}
+	return signing(d, m, a)
+}
+
+// readAux returns the nonce function masked by 32 bytes of auxiliary random data read from rand.
+func readAux(rand io.Reader) (auxNonce, error) {
+	a := make([]byte, 32)
+	if _, err := io.ReadFull(rand, a); err != nil {
+		return nil, fmt.Errorf("reading auxiliary random data : %v", err)
+	}
+	return auxNonce(a), nil
 }
 
 // golang big.Int
@@ -0,0 +1,138 @@
+package bipschnorr
+
+// BIP340 as deployed with Taproot.
+// https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
+// Public keys are 32 byte X coordinates, R has an even Y coordinate and the hashes are tagged.
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+// The function hash_tag(x), where tag is a UTF-8 encoded tag name and x is a byte array,
+// returns the 32 byte hash SHA256(SHA256(tag) || SHA256(tag) || x).
+func hashTag(tag string, x []byte) []byte {
+	th := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(th[:])
+	h.Write(th[:])
+	h.Write(x)
+	return h.Sum(nil)
+}
+
+// The function has_even_y(P), where P is a point for which not is_infinite(P), returns y(P) mod 2 = 0.
+func hasEvenY(P *Point) bool {
+	return y(P).Bit(0) == 0
+}
+
+// The function lift_x(x), where x is a 256-bit unsigned integer, returns the point P for which x(P) = x and has_even_y(P),
+// or fails (returns nil) if x is greater than p-1 or no such point exists.
+func liftX(x *big.Int) *Point {
+	var a affinePoint
+	// Fail if x ≥ p.
+	if x.Sign() < 0 || x.Cmp(p) >= 0 {
+		return nil
+	}
+	// Let c = x^3 + 7 mod p and y = c^((p+1)/4) mod p; fail if c ≠ y^2 mod p.
+	var c fieldVal
+	a.x.setInt(x)
+	c.sqr(&a.x).mul(&c, &a.x).add(&c, &fieldVal{7, 0, 0, 0})
+	if !a.y.sqrt(&c) {
+		return nil
+	}
+	// Return the unique point P such that x(P) = x and y(P) = y if y mod 2 = 0 or y(P) = p-y otherwise.
+	if a.y.isOdd() {
+		a.y.neg(&a.y)
+	}
+	return a.point()
+}
+
+// PubKeyBIP340 returns the 32 byte public key bytes(dG) for the secret key d: an integer in the range 1..n-1.
+func PubKeyBIP340(d *big.Int) []byte {
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil
+	}
+	var sd scalar
+	return scalarBaseMul(sd.setInt(d)).BytesXOnly()
+}
+
+// SigningBIP340 is
+// Input:
+// The secret key d: an integer in the range 1..n-1
+// The message m: a byte array
+// Auxiliary random data a: a 32 byte array
+func SigningBIP340(d *big.Int, m []byte, a []byte) []byte {
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil
+	}
+	if len(a) != 32 {
+		return nil
+	}
+	// Let P = d'G.
+	var sd, nd, k, nk, e scalar
+	sd.setInt(d)
+	P := scalarBaseMul(&sd)
+	// Let d = d' if has_even_y(P), otherwise let d = n - d'.
+	sd.cmov(nd.neg(&sd), !hasEvenY(P))
+	// Let t be the byte-wise xor of bytes(d) and hash_BIP0340/aux(a).
+	t := sd.bytes()
+	for i, b := range hashTag("BIP0340/aux", a) {
+		t[i] ^= b
+	}
+	// Let rand = hash_BIP0340/nonce(t || bytes(P) || m).
+	rand := hashTag("BIP0340/nonce", ll(t, P.BytesXOnly(), m))
+	// Let k' = int(rand) mod n; fail if k' = 0.
+	k.setBytes(rand)
+	if k.isZero() {
+		return nil
+	}
+	// Let R = k'G.
+	R := scalarBaseMul(&k)
+	// Let k = k' if has_even_y(R), otherwise let k = n - k'.
+	k.cmov(nk.neg(&k), !hasEvenY(R))
+	// Let e = int(hash_BIP0340/challenge(bytes(R) || bytes(P) || m)) mod n.
+	e.setBytes(hashTag("BIP0340/challenge", ll(bytes(x(R)), P.BytesXOnly(), m)))
+	// Let sig = bytes(R) || bytes((k + ed) mod n).
+	return ll(bytes(x(R)), e.mul(&e, &sd).add(&e, &k).bytes())
+}
+
+// VerificationBIP340 is
+// Input:
+// The public key pk: a 32 byte array
+// The message m: a byte array
+// A signature sig: a 64 byte array
+func VerificationBIP340(pk []byte, m []byte, sig []byte) bool {
+	if len(pk) != 32 {
+		return false
+	}
+	if len(sig) != 64 {
+		return false
+	}
+	// The algorithm Verify(pk, m, sig) is defined as:
+	// Let P = lift_x(int(pk)); fail if that fails.
+	P := liftX(intbs(pk))
+	if P == nil {
+		return false
+	}
+	// Let r = int(sig[0:32]); fail if r ≥ p.
+	r := intbs(sig[0:32])
+	if r.Cmp(p) >= 0 {
+		return false
+	}
+	// Let s = int(sig[32:64]); fail if s ≥ n.
+	s := intbs(sig[32:64])
+	if s.Cmp(n) >= 0 {
+		return false
+	}
+	// Let e = int(hash_BIP0340/challenge(bytes(r) || bytes(P) || m)) mod n.
+	e := intbs(hashTag("BIP0340/challenge", ll(sig[0:32], pk, m)))
+	// Let R = sG - eP.
+	var ks scalar
+	J := ecmult(ks.setInt(s), []ecmultTerm{term(mod(sub(n, e), n), P)})
+	R := J.point()
+	// Fail if is_infinite(R) or not has_even_y(R) or x(R) ≠ r.
+	if infinite(R) || !hasEvenY(R) || x(R).Cmp(r) != 0 {
+		return false
+	}
+	return true
+}
@@ -3,6 +3,8 @@ package bipschnorr_test
 import (
 	"github.com/tnakagawa/bipschnorr"
 
+	"bytes"
+	"crypto/rand"
 	"encoding/hex"
 	"math/big"
 	"reflect"
@@ -149,6 +151,9 @@ func TestVector5(t *testing.T) {
 	if v {
 		t.Errorf("success Schnorr_verify : %+v", v)
 	}
+	if err := bipschnorr.Verify(pub, msg, sign); err != bipschnorr.ErrPubKeyNotOnCurve {
+		t.Errorf("Verify : %v", err)
+	}
 }
 
 func TestVector6(t *testing.T) {
@@ -169,6 +174,9 @@ func TestVector6(t *testing.T) {
 	if v {
 		t.Errorf("success Schnorr_verify : %+v", v)
 	}
+	if err := bipschnorr.Verify(pub, msg, sign); err != bipschnorr.ErrRJacobi {
+		t.Errorf("Verify : %v", err)
+	}
 }
 
 func TestVector7(t *testing.T) {
@@ -249,6 +257,9 @@ func TestVector10(t *testing.T) {
 	if v {
 		t.Errorf("success Schnorr_verify : %+v", v)
 	}
+	if err := bipschnorr.Verify(pub, msg, sign); err != bipschnorr.ErrRInfinite {
+		t.Errorf("Verify : %v", err)
+	}
 }
 
 func TestVector11(t *testing.T) {
@@ -289,6 +300,9 @@ func TestVector12(t *testing.T) {
 	if v {
 		t.Errorf("success Schnorr_verify : %+v", v)
 	}
+	if err := bipschnorr.Verify(pub, msg, sign); err != bipschnorr.ErrSigROutOfRange {
+		t.Errorf("Verify : %v", err)
+	}
 }
 
 func TestVector13(t *testing.T) {
@@ -309,4 +323,125 @@ func TestVector13(t *testing.T) {
 	if v {
 		t.Errorf("success Schnorr_verify : %+v", v)
 	}
+	if err := bipschnorr.Verify(pub, msg, sign); err != bipschnorr.ErrSigSOutOfRange {
+		t.Errorf("Verify : %v", err)
+	}
+}
+
+func TestSigningWithAux(t *testing.T) {
+	// Test vector 2
+	pri, _ := new(big.Int).SetString("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", 16)
+	pub := bipschnorr.NewPoint(pri)
+	msg, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	sign, _ := hex.DecodeString("2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D1E51A22CCEC35599B8F266912281F8365FFC2D035A230434A1A64DC59F7013FD")
+
+	// All zero auxiliary data reproduces the signing vectors 1 to 3.
+	for _, v := range [][3]string{
+		{"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"787A848E71043D280C50470E8E1532B2DD5D20EE912A45DBDD2BD1DFBF187EF67031A98831859DC34DFFEEDDA86831842CCD0079E1F92AF177F7F22CC1DCED05"},
+		{"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D1E51A22CCEC35599B8F266912281F8365FFC2D035A230434A1A64DC59F7013FD"},
+		{"C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C7",
+			"5E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+			"00DA9B08172A9B6F0466A2DEFD817F2D7AB437E0D253CB5395A963866B3574BE00880371D01766935B92D2AB4CD5C8A2A5837EC57FED7660773A05F0DE142380"},
+	} {
+		d, _ := new(big.Int).SetString(v[0], 16)
+		m, _ := hex.DecodeString(v[1])
+		expected, _ := hex.DecodeString(v[2])
+		if sig := bipschnorr.SigningWithAux(d, m, make([]byte, 32)); !reflect.DeepEqual(expected, sig) {
+			t.Errorf("no match Schnorr_sign:%x", sig)
+		}
+	}
+
+	aux, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	sig := bipschnorr.SigningWithAux(pri, msg, aux)
+	if reflect.DeepEqual(sign, sig) {
+		t.Errorf("auxiliary data not used")
+	}
+	if !bipschnorr.Verification(pub, msg, sig) {
+		t.Errorf("fail Schnorr_verify : %x", sig)
+	}
+	if !reflect.DeepEqual(sig, bipschnorr.SigningWithAux(pri, msg, aux)) {
+		t.Errorf("not deterministic for the same auxiliary data")
+	}
+
+	sig, err := bipschnorr.SigningWithRand(pri, msg, rand.Reader)
+	if err != nil {
+		t.Fatalf("SigningWithRand : %v", err)
+	}
+	if !bipschnorr.Verification(pub, msg, sig) {
+		t.Errorf("fail Schnorr_verify : %x", sig)
+	}
+	if _, err := bipschnorr.SigningWithRand(pri, msg, bytes.NewReader(aux[:31])); err == nil {
+		t.Errorf("short auxiliary data accepted")
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	// Test vector 3 public key, whose Y coordinate is odd.
+	pubbs, _ := hex.DecodeString("03FAC2114C2FBB091527EB7C64ECB11F8021CB45E8E7809D3C0938E4B8C0E5F84B")
+	pub := bipschnorr.NewPointForPub(pubbs)
+	hybrid := pub.BytesUncompressed()
+	hybrid[0] = 0x07
+	for _, bs := range [][]byte{pubbs, pub.BytesUncompressed(), hybrid} {
+		P, err := bipschnorr.ParsePoint(bs)
+		if err != nil {
+			t.Errorf("ParsePoint(%x) : %v", bs, err)
+			continue
+		}
+		if !reflect.DeepEqual(P, pub) {
+			t.Errorf("no match ParsePoint(%x) : %x", bs, P.Bytes())
+		}
+	}
+	// An x-only key has an even Y coordinate.
+	P, err := bipschnorr.ParsePoint(pub.BytesXOnly())
+	if err != nil {
+		t.Fatalf("ParsePoint : %v", err)
+	}
+	if !reflect.DeepEqual(P.BytesXOnly(), pub.BytesXOnly()) || P.Bytes()[0] != 0x02 {
+		t.Errorf("no match ParsePoint x-only : %x", P.Bytes())
+	}
+
+	hybrid[0] = 0x06
+	offcurve := pub.BytesUncompressed()
+	offcurve[64] ^= 1
+	for _, bs := range [][]byte{
+		hybrid,
+		offcurve,
+		pubbs[:32],
+		append([]byte{0x04}, pubbs[1:]...),
+		// Test vector 5: not on the curve
+		{0x03, 0xEE, 0xFD, 0xEA, 0x4C, 0xDB, 0x67, 0x77, 0x50, 0xA4, 0x20, 0xFE, 0xE8, 0x07, 0xEA, 0xCF, 0x21,
+			0xEB, 0x98, 0x98, 0xAE, 0x79, 0xB9, 0x76, 0x87, 0x66, 0xE4, 0xFA, 0xA0, 0x4A, 0x2D, 0x4A, 0x34},
+		// x equal to p + 1
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+			0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE, 0xFF, 0xFF, 0xFC, 0x30},
+	} {
+		if _, err := bipschnorr.ParsePoint(bs); err == nil {
+			t.Errorf("ParsePoint accepted %x", bs)
+		}
+		if P := bipschnorr.NewPointForPub(bs); P != nil {
+			t.Errorf("NewPointForPub accepted %x", bs)
+		}
+	}
+}
+
+func BenchmarkSigning(b *testing.B) {
+	pri, _ := new(big.Int).SetString("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", 16)
+	msg, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	for i := 0; i < b.N; i++ {
+		bipschnorr.Signing(pri, msg)
+	}
+}
+
+func BenchmarkVerification(b *testing.B) {
+	pubbs, _ := hex.DecodeString("02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659")
+	pub := bipschnorr.NewPointForPub(pubbs)
+	msg, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	sign, _ := hex.DecodeString("2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D1E51A22CCEC35599B8F266912281F8365FFC2D035A230434A1A64DC59F7013FD")
+	for i := 0; i < b.N; i++ {
+		bipschnorr.Verification(pub, msg, sign)
+	}
 }
@@ -0,0 +1,143 @@
+package bipschnorr
+
+// Adaptor signatures.
+// A pre-signature for an adaptor point T = tG is bytes(R) || bytes(s') with R = kG + T, where bytes(R) is the 33 byte point.
+// Knowing t completes it into a signature that passes Verification, and the two together reveal t.
+// The draft requires jacobi(y(R)) = 1 for the completed signature while only k can be negated before t is known,
+// so when jacobi(y(R)) ≠ 1 the signature is made for -R = -kG - T instead and t is subtracted rather than added.
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// PreSign is
+// Input:
+// The secret key d: an integer in the range 1..n-1.
+// The message m: an array of 32 bytes
+// The adaptor point T: a point
+func PreSign(d *big.Int, m []byte, T *Point) []byte {
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil
+	}
+	if len(m) != 32 {
+		return nil
+	}
+	if !oncurve(T) {
+		return nil
+	}
+	// Let k = int(hash(bytes(d) || m || bytes(T))) mod n; fail if k = 0.
+	var sd, k, nk, e scalar
+	sd.setInt(d)
+	k.setBytes(hash(ll(bytes(d), m, T.Bytes())))
+	if k.isZero() {
+		return nil
+	}
+	// Let R = kG + T; fail if infinite(R).
+	J := gBase.mul(&k)
+	R := J.addPoint(T).point()
+	if infinite(R) {
+		return nil
+	}
+	// If jacobi(y(R)) ≠ 1, let k = n - k.
+	k.cmov(nk.neg(&k), jacobi(y(R)).Cmp(big.NewInt(1)) != 0)
+	// Let e = int(hash(bytes(x(R)) || bytes(dG) || m)) mod n.
+	e.setBytes(hash(ll(bytes(x(R)), scalarBaseMul(&sd).Bytes(), m)))
+	// The pre-signature is bytes(R) || bytes(k + ed mod n).
+	return ll(R.Bytes(), e.mul(&e, &sd).add(&e, &k).bytes())
+}
+
+// PreVerify is
+// Input:
+// The public key P: a point
+// The message m: a 32 byte array
+// The adaptor point T: a point
+// A pre-signature presig: a 65 byte array
+func PreVerify(P *Point, m []byte, T *Point, presig []byte) bool {
+	if len(m) != 32 {
+		return false
+	}
+	// Fail if not oncurve(P) or not oncurve(T).
+	if !oncurve(P) || !oncurve(T) {
+		return false
+	}
+	R, s, err := parsePreSig(presig)
+	if err != nil {
+		return false
+	}
+	// Let e = int(hash(bytes(x(R)) || bytes(P) || m)) mod n.
+	e := intbs(hash(ll(bytes(x(R)), P.Bytes(), m)))
+	// Let R' = s'G - eP.
+	var ks scalar
+	J := ecmult(ks.setInt(s), []ecmultTerm{term(mod(sub(n, e), n), P)})
+	// Fail if R' ≠ R - T when jacobi(y(R)) = 1, or R' ≠ T - R otherwise.
+	D := T.jacobian()
+	D.neg(&D).addPoint(R)
+	if jacobi(y(R)).Cmp(big.NewInt(1)) != 0 {
+		D.neg(&D)
+	}
+	return !D.isInfinity() && J.equal(&D)
+}
+
+// Adapt completes the pre-signature presig with the secret t of its adaptor point into a signature bytes(x(R)) || bytes(s).
+func Adapt(presig []byte, t *big.Int) ([]byte, error) {
+	R, s, err := parsePreSig(presig)
+	if err != nil {
+		return nil, err
+	}
+	// Let s = s' + t if jacobi(y(R)) = 1, otherwise s = s' - t.
+	var ss, st scalar
+	ss.setInt(s)
+	st.setInt(t)
+	if jacobi(y(R)).Cmp(big.NewInt(1)) == 0 {
+		ss.add(&ss, &st)
+	} else {
+		ss.sub(&ss, &st)
+	}
+	return ll(bytes(x(R)), ss.bytes()), nil
+}
+
+// Extract returns the secret t of the adaptor point from the pre-signature presig and its completed signature sig.
+func Extract(presig []byte, sig []byte) (*big.Int, error) {
+	R, s, err := parsePreSig(presig)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != 64 {
+		return nil, ErrSigLength
+	}
+	if intbs(sig[0:32]).Cmp(x(R)) != 0 {
+		return nil, ErrRMismatch
+	}
+	// Let t = s - s' if jacobi(y(R)) = 1, otherwise t = s' - s.
+	var ss, st scalar
+	ss.setInt(s)
+	if !st.setBytes(sig[32:64]) {
+		return nil, ErrSigSOutOfRange
+	}
+	if jacobi(y(R)).Cmp(big.NewInt(1)) == 0 {
+		st.sub(&st, &ss)
+	} else {
+		st.sub(&ss, &st)
+	}
+	return st.bigInt(), nil
+}
+
+// parsePreSig returns R and s' of a pre-signature.
+func parsePreSig(presig []byte) (*Point, *big.Int, error) {
+	if len(presig) != 65 {
+		return nil, nil, fmt.Errorf("pre-signature must be 65 bytes, got %d", len(presig))
+	}
+	if presig[0] != 0x02 && presig[0] != 0x03 {
+		return nil, nil, fmt.Errorf("invalid prefix 0x%02x for R", presig[0])
+	}
+	R, err := ParsePoint(presig[0:33])
+	if err != nil {
+		return nil, nil, err
+	}
+	s := intbs(presig[33:65])
+	if s.Cmp(n) >= 0 {
+		return nil, nil, ErrSigSOutOfRange
+	}
+	return R, s, nil
+}
@@ -0,0 +1,47 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"testing"
+)
+
+func TestAdaptor(t *testing.T) {
+	// Both signs of jacobi(y(kG + T)) occur within a few rounds.
+	for i := 0; i < 16; i++ {
+		d := rndbi()
+		P := bipschnorr.NewPoint(d)
+		m := rndbs()
+		s := rndbi()
+		T := bipschnorr.NewPoint(s)
+
+		presig := bipschnorr.PreSign(d, m, T)
+		if presig == nil {
+			t.Fatalf("fail PreSign")
+		}
+		if !bipschnorr.PreVerify(P, m, T, presig) {
+			t.Fatalf("fail PreVerify : %x", presig)
+		}
+		if bipschnorr.PreVerify(P, m, P, presig) {
+			t.Errorf("success PreVerify for another adaptor point")
+		}
+		if bipschnorr.Verification(P, m, append(presig[1:33:33], presig[33:]...)) {
+			t.Errorf("success Verification for a pre-signature")
+		}
+
+		sig, err := bipschnorr.Adapt(presig, s)
+		if err != nil {
+			t.Fatalf("Adapt : %v", err)
+		}
+		if err := bipschnorr.Verify(P, m, sig); err != nil {
+			t.Fatalf("Verify : %v", err)
+		}
+		e, err := bipschnorr.Extract(presig, sig)
+		if err != nil {
+			t.Fatalf("Extract : %v", err)
+		}
+		if e.Cmp(s) != 0 {
+			t.Errorf("no match Extract : %x, %x", e, s)
+		}
+	}
+}
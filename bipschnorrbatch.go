@@ -0,0 +1,140 @@
+package bipschnorr
+
+import "encoding/binary"
+
+// batchItem is a (P, m, sig) triple prepared for batch verification.
+type batchItem struct {
+	P, R jacobianPoint
+	s, e scalar
+}
+
+// BatchVerification is
+// Input:
+// The number u of signatures
+// The public keys P1...u: u points
+// The messages m1...u: u 32-byte arrays
+// The signatures sig1...u: u 64-byte arrays
+// It returns whether every triple passes Verification, at about the cost of one multi-scalar multiplication.
+func BatchVerification(Ps []*Point, ms [][]byte, sigs [][]byte) bool {
+	items, seed, bad := parseBatch(Ps, ms, sigs)
+	if bad >= 0 {
+		return false
+	}
+	return batchCheck(items, seed, 0)
+}
+
+// BatchVerificationIndex returns the index of the first triple that fails Verification, or -1 if all of them pass.
+// A failing batch is bisected, so k invalid signatures among u cost about k log u batch checks.
+func BatchVerificationIndex(Ps []*Point, ms [][]byte, sigs [][]byte) int {
+	items, seed, bad := parseBatch(Ps, ms, sigs)
+	if bad >= 0 {
+		return bad
+	}
+	return firstInvalid(items, seed, 0)
+}
+
+// firstInvalid returns the index of the first invalid item, or -1, where items start at index off of the batch.
+func firstInvalid(items []batchItem, seed []byte, off int) int {
+	if batchCheck(items, seed, off) {
+		return -1
+	}
+	if len(items) == 1 {
+		return off
+	}
+	h := len(items) / 2
+	if i := firstInvalid(items[:h], seed, off); i >= 0 {
+		return i
+	}
+	return firstInvalid(items[h:], seed, off+h)
+}
+
+// parseBatch prepares every triple and returns the seed of the batch,
+// or the index of the first triple that fails on its own (a missing triple counts as failing).
+func parseBatch(Ps []*Point, ms [][]byte, sigs [][]byte) ([]batchItem, []byte, int) {
+	u := len(Ps)
+	if len(ms) > u {
+		u = len(ms)
+	}
+	if len(sigs) > u {
+		u = len(sigs)
+	}
+	items := make([]batchItem, u)
+	for i := range items {
+		if i >= len(Ps) || i >= len(ms) || i >= len(sigs) || !items[i].parse(Ps[i], ms[i], sigs[i]) {
+			return nil, nil, i
+		}
+	}
+	// seed = seed_hash(pk1..pku || m1..mu || sig1..sigu)
+	bs := []byte{}
+	for _, P := range Ps {
+		bs = append(bs, P.Bytes()...)
+	}
+	for _, m := range ms {
+		bs = append(bs, m...)
+	}
+	for _, sig := range sigs {
+		bs = append(bs, sig...)
+	}
+	return items, hash(bs), -1
+}
+
+// parse sets b from a triple and reports whether the triple passes the checks that do not involve other triples.
+func (b *batchItem) parse(P *Point, m []byte, sig []byte) bool {
+	if len(m) != 32 || len(sig) != 64 {
+		return false
+	}
+	// Fail if not oncurve(Pi).
+	if !oncurve(P) {
+		return false
+	}
+	// Let r = int(sig[0:32]); fail if r ≥ p.
+	var r, c, y fieldVal
+	if !r.setBytes(sig[0:32]) {
+		return false
+	}
+	// Let s = int(sig[32:64]); fail if s ≥ n.
+	if !b.s.setBytes(sig[32:64]) {
+		return false
+	}
+	// Let e = int(hash(bytes(r) || bytes(P) || m)) mod n.
+	b.e.setBytes(hash(ll(sig[0:32], P.Bytes(), m)))
+	// Let c = (r^3 + 7) mod p and y = c^((p+1)/4) mod p; fail if c ≠ y^2 mod p.
+	c.sqr(&r).mul(&c, &r).add(&c, &fieldVal{7, 0, 0, 0})
+	if !y.sqrt(&c) {
+		return false
+	}
+	// Let R = (r, y).
+	b.R.setAffine(&affinePoint{x: r, y: y})
+	b.P = P.jacobian()
+	return true
+}
+
+// batchCheck returns whether (s1 + a2s2 + ... + ausu)G = R1 + a2R2 + ... + auRu + e1P1 + (a2e2)P2 + ... + (aueu)Pu
+// for items starting at index off of the batch.
+func batchCheck(items []batchItem, seed []byte, off int) bool {
+	var g, a, t scalar
+	terms := make([]ecmultTerm, 0, 2*len(items))
+	for i := range items {
+		a.batchCoefficient(seed, off+i)
+		g.add(&g, t.mul(&a, &items[i].s))
+		a.neg(&a)
+		terms = append(terms, ecmultTerm{a, items[i].R}, ecmultTerm{*t.mul(&a, &items[i].e), items[i].P})
+	}
+	R := ecmultMulti(&g, terms)
+	return R.isInfinity()
+}
+
+// batchCoefficient sets r to the coefficient a_i of the triple at index i:
+// 1 for the first triple, otherwise int(hash(seed || i)) mod n generated from the seed of the batch.
+func (r *scalar) batchCoefficient(seed []byte, i int) *scalar {
+	if i == 0 {
+		return r.setUint(1)
+	}
+	bs := make([]byte, 8)
+	binary.BigEndian.PutUint64(bs, uint64(i))
+	r.setBytes(hash(ll(seed, bs)))
+	if r.isZero() {
+		r.setUint(1)
+	}
+	return r
+}
@@ -0,0 +1,113 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestBatchVerificationVectors(t *testing.T) {
+	var Ps []*bipschnorr.Point
+	var ms, sigs [][]byte
+	add := func(pub, msg, sig string) {
+		pubbs, _ := hex.DecodeString(pub)
+		m, _ := hex.DecodeString(msg)
+		s, _ := hex.DecodeString(sig)
+		Ps = append(Ps, bipschnorr.NewPointForPub(pubbs))
+		ms = append(ms, m)
+		sigs = append(sigs, s)
+	}
+	// Test vectors 1, 2, 3, 4 and 4B.
+	add("0279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"787A848E71043D280C50470E8E1532B2DD5D20EE912A45DBDD2BD1DFBF187EF67031A98831859DC34DFFEEDDA86831842CCD0079E1F92AF177F7F22CC1DCED05")
+	add("02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D1E51A22CCEC35599B8F266912281F8365FFC2D035A230434A1A64DC59F7013FD")
+	add("03FAC2114C2FBB091527EB7C64ECB11F8021CB45E8E7809D3C0938E4B8C0E5F84B",
+		"5E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+		"00DA9B08172A9B6F0466A2DEFD817F2D7AB437E0D253CB5395A963866B3574BE00880371D01766935B92D2AB4CD5C8A2A5837EC57FED7660773A05F0DE142380")
+	add("03DEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+		"4DF3C3F68FCC83B27E9D42C90431A72499F17875C81A599B566C9889B9696703",
+		"00000000000000000000003B78CE563F89A0ED9414F5AA28AD0D96D6795F9C6302A8DC32E64E86A333F20EF56EAC9BA30B7246D6D25E22ADB8C6BE1AEB08D49D")
+	add("031B84C5567B126440995D3ED5AABA0565D71E1834604819FF9C17F5E9D5DD078F",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"52818579ACA59767E3291D91B76B637BEF062083284992F2D95F564CA6CB4E3530B1DA849C8E8304ADC0CFE870660334B3CFC18E825EF1DB34CFAE3DFC5D8187")
+
+	if !bipschnorr.BatchVerification(Ps, ms, sigs) {
+		t.Errorf("fail BatchVerification")
+	}
+	if i := bipschnorr.BatchVerificationIndex(Ps, ms, sigs); i != -1 {
+		t.Errorf("BatchVerificationIndex : %d", i)
+	}
+
+	// Test vector 6 (incorrect R residuosity).
+	add("02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1DFA16AEE06609280A19B67A24E1977E4697712B5FD2943914ECD5F730901B4AB7")
+	if bipschnorr.BatchVerification(Ps, ms, sigs) {
+		t.Errorf("success BatchVerification")
+	}
+	if i := bipschnorr.BatchVerificationIndex(Ps, ms, sigs); i != 5 {
+		t.Errorf("BatchVerificationIndex : %d", i)
+	}
+
+	// A missing signature fails.
+	if i := bipschnorr.BatchVerificationIndex(Ps[:5], ms[:5], sigs[:4]); i != 4 {
+		t.Errorf("BatchVerificationIndex : %d", i)
+	}
+}
+
+func TestBatchVerification(t *testing.T) {
+	// More than 32 signatures take the Pippenger path.
+	Ps, ms, sigs := batch(40)
+	if !bipschnorr.BatchVerification(Ps, ms, sigs) {
+		t.Errorf("fail BatchVerification")
+	}
+	if i := bipschnorr.BatchVerificationIndex(Ps, ms, sigs); i != -1 {
+		t.Errorf("BatchVerificationIndex : %d", i)
+	}
+	// A signature for another message fails.
+	j := rndi(len(sigs))
+	ms[j] = rndbs()
+	if bipschnorr.BatchVerification(Ps, ms, sigs) {
+		t.Errorf("success BatchVerification")
+	}
+	if i := bipschnorr.BatchVerificationIndex(Ps, ms, sigs); i != j {
+		t.Errorf("BatchVerificationIndex : %d, want %d", i, j)
+	}
+	// Two invalid signatures whose errors cancel in a plain sum still fail.
+	Ps, ms, sigs = batch(2)
+	s0 := new(big.Int).SetBytes(sigs[0][32:])
+	s1 := new(big.Int).SetBytes(sigs[1][32:])
+	s0.Add(s0, big.NewInt(1))
+	s1.Sub(s1, big.NewInt(1))
+	s0.FillBytes(sigs[0][32:])
+	s1.FillBytes(sigs[1][32:])
+	if bipschnorr.BatchVerification(Ps, ms, sigs) {
+		t.Errorf("success BatchVerification")
+	}
+}
+
+func batch(u int) ([]*bipschnorr.Point, [][]byte, [][]byte) {
+	Ps := make([]*bipschnorr.Point, u)
+	ms := make([][]byte, u)
+	sigs := make([][]byte, u)
+	for i := range Ps {
+		d := rndbi()
+		Ps[i] = bipschnorr.NewPoint(d)
+		ms[i] = rndbs()
+		sigs[i] = bipschnorr.Signing(d, ms[i])
+	}
+	return Ps, ms, sigs
+}
+
+func BenchmarkBatchVerification(b *testing.B) {
+	Ps, ms, sigs := batch(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bipschnorr.BatchVerification(Ps, ms, sigs)
+	}
+}
@@ -0,0 +1,65 @@
+package bipschnorr
+
+// Sign-to-contract.
+// The signer commits to data inside the nonce point, R = R0 + int(hash(bytes(R0) || data))G,
+// so the signature keeps its format and passes Verification, and R0 opens the commitment.
+
+import "math/big"
+
+// SignToContract is Signing with data committed to in the nonce. It returns the signature and the original nonce point R0,
+// which is the opening proof for VerifyCommitment.
+// Input:
+// The secret key d: an integer in the range 1..n-1.
+// The message m: an array of 32 bytes
+// The data to commit to: a byte array
+func SignToContract(d *big.Int, m []byte, data []byte) ([]byte, *Point) {
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, nil
+	}
+	if len(m) != 32 {
+		return nil, nil
+	}
+	// Let k0 = int(hash(bytes(d) || m || hash(data))) mod n; the data is included so that no nonce is reused with two tweaks.
+	var sd, k, c, e scalar
+	sd.setInt(d)
+	k.setBytes(hash(ll(bytes(d), m, hash(data))))
+	// Let R0 = k0G.
+	R0 := scalarBaseMul(&k)
+	// Let k = k0 + int(hash(bytes(R0) || data)) mod n; fail if k = 0.
+	c.setBytes(hash(ll(R0.Bytes(), data)))
+	k.add(&k, &c)
+	if k.isZero() {
+		return nil, nil
+	}
+	// Let R = kG.
+	R := scalarBaseMul(&k)
+	// If jacobi(y(R)) ≠ 1, let k = n - k.
+	if jacobi(y(R)).Cmp(big.NewInt(1)) != 0 {
+		k.neg(&k)
+	}
+	// Let e = int(hash(bytes(x(R)) || bytes(dG) || m)) mod n.
+	e.setBytes(hash(ll(bytes(x(R)), scalarBaseMul(&sd).Bytes(), m)))
+	// The signature is bytes(x(R)) || bytes(k + ed mod n).
+	return ll(bytes(x(R)), e.mul(&e, &sd).add(&e, &k).bytes()), R0
+}
+
+// VerifyCommitment returns whether the signature sig commits to data with the original nonce point R0.
+// It does not verify the signature itself, which is Verification's job.
+func VerifyCommitment(sig []byte, R0 *Point, data []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	if !oncurve(R0) {
+		return false
+	}
+	// Let R = R0 + int(hash(bytes(R0) || data))G.
+	var c scalar
+	c.setBytes(hash(ll(R0.Bytes(), data)))
+	J := gBase.mul(&c)
+	R := J.addPoint(R0).point()
+	// Fail if infinite(R) or x(R) ≠ int(sig[0:32]). The sign of R is fixed by the signature's jacobi rule.
+	if infinite(R) || x(R).Cmp(intbs(sig[0:32])) != 0 {
+		return false
+	}
+	return true
+}
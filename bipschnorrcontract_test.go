@@ -0,0 +1,32 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"testing"
+)
+
+func TestSignToContract(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		d := rndbi()
+		P := bipschnorr.NewPoint(d)
+		m := rndbs()
+		data := []byte("2026-10-18 block 1234567")
+		sig, R0 := bipschnorr.SignToContract(d, m, data)
+		if sig == nil {
+			t.Fatalf("fail SignToContract")
+		}
+		if err := bipschnorr.Verify(P, m, sig); err != nil {
+			t.Fatalf("Verify : %v", err)
+		}
+		if !bipschnorr.VerifyCommitment(sig, R0, data) {
+			t.Errorf("fail VerifyCommitment")
+		}
+		if bipschnorr.VerifyCommitment(sig, R0, []byte("other data")) {
+			t.Errorf("success VerifyCommitment for other data")
+		}
+		if bipschnorr.VerifyCommitment(bipschnorr.Signing(d, m), R0, data) {
+			t.Errorf("success VerifyCommitment for a plain signature")
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package bipschnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Coordinator collects the messages of the users of a multisignature and broadcasts them,
+// so every user talks to the coordinator alone instead of to every other user.
+// It holds public data only, checks the hashes, random points and signs it receives, and outputs the multisignature.
+type Coordinator struct {
+	u  int            // number of users
+	m  []byte         // message
+	ps []*Point       // public keys of all users
+	hs [][]byte       // hash values of all users
+	rs []*Point       // random points of all users
+	ss [][]byte       // signs of all users
+	ka *KeyAggContext // key aggregation of all users
+
+	tweaked bool   // whether the users sign for the Taproot output key
+	root    []byte // merkle root of the Taproot tweak
+}
+
+// NewCoordinator returns Coordinator for u users signing m.
+func NewCoordinator(u int, m []byte) (*Coordinator, error) {
+	if u < 1 || m == nil {
+		return nil, fmt.Errorf("illegal parameter")
+	}
+	c := &Coordinator{}
+	c.u = u
+	c.m = m
+	c.ps = make([]*Point, u)
+	c.hs = make([][]byte, u)
+	c.rs = make([]*Point, u)
+	c.ss = make([][]byte, u)
+	return c, nil
+}
+
+// SetTweak makes the multisignature valid for the Taproot output key, as Muser.SetTweak does for every user.
+func (c *Coordinator) SetTweak(merkleRoot []byte) error {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return fmt.Errorf("illegal parameter")
+	}
+	if c.step() > 0 {
+		return fmt.Errorf("public keys already received, too late for the tweak")
+	}
+	c.tweaked = true
+	c.root = merkleRoot
+	c.ka = nil
+	return nil
+}
+
+// SetPublicKey sets the public key of user(i), failing if it is already set or hash values have been received.
+func (c *Coordinator) SetPublicKey(i int, pubkey *Point) error {
+	if i < 1 || c.u < i || !oncurve(pubkey) {
+		return fmt.Errorf("illegal parameter")
+	}
+	if c.step() > 1 {
+		return fmt.Errorf("hash values already received, too late for public keys")
+	}
+	if c.ps[i-1] != nil {
+		return fmt.Errorf("public key from the user(%d) already received", i)
+	}
+	c.ps[i-1] = pubkey
+	c.ka = nil
+	return nil
+}
+
+// PublicKeys returns the public keys of all users to broadcast, for Muser.SetPublicKey.
+func (c *Coordinator) PublicKeys() ([]*Point, error) {
+	for j, P := range c.ps {
+		if P == nil {
+			return nil, fmt.Errorf("not received public key from the user(%d)", j+1)
+		}
+	}
+	if c.ka == nil {
+		ka, err := KeyAgg(c.ps)
+		if err != nil {
+			return nil, err
+		}
+		c.ka = ka
+		if c.tweaked {
+			if err := c.ka.TaprootTweak(c.root); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return append([]*Point{}, c.ps...), nil
+}
+
+// Q returns the key the multisignature is valid for.
+func (c *Coordinator) Q() (*Point, error) {
+	if _, err := c.PublicKeys(); err != nil {
+		return nil, err
+	}
+	return c.ka.Q(), nil
+}
+
+// SetHash sets the hash value of the random point of user(i),
+// failing if it is already set, a public key is missing or random points have been received.
+func (c *Coordinator) SetHash(i int, h []byte) error {
+	if i < 1 || c.u < i || len(h) != 32 {
+		return fmt.Errorf("illegal parameter")
+	}
+	if c.step() > 2 {
+		return fmt.Errorf("random points already received, too late for hash values")
+	}
+	if c.hs[i-1] != nil {
+		return fmt.Errorf("hash value from the user(%d) already received", i)
+	}
+	// The public keys must all be broadcast before any hash value.
+	if _, err := c.PublicKeys(); err != nil {
+		return err
+	}
+	c.hs[i-1] = append([]byte{}, h...)
+	return nil
+}
+
+// Hashes returns the hash values of all users to broadcast, for Muser.SetHash.
+func (c *Coordinator) Hashes() ([][]byte, error) {
+	for j, h := range c.hs {
+		if h == nil {
+			return nil, fmt.Errorf("not received hash value from the user(%d)", j+1)
+		}
+	}
+	hs := [][]byte{}
+	for _, h := range c.hs {
+		hs = append(hs, append([]byte{}, h...))
+	}
+	return hs, nil
+}
+
+// SetRandomPoint sets the random point of user(i), failing if it does not match the hash value of user(i),
+// if it is already set or if signs have been received.
+func (c *Coordinator) SetRandomPoint(i int, R *Point) error {
+	if i < 1 || c.u < i || !oncurve(R) {
+		return fmt.Errorf("illegal parameter")
+	}
+	if c.step() > 3 {
+		return fmt.Errorf("signs already received, too late for random points")
+	}
+	if c.rs[i-1] != nil {
+		return fmt.Errorf("random point from the user(%d) already received", i)
+	}
+	// The hash values must all be broadcast before any random point, or a user could choose R after seeing the others.
+	if _, err := c.Hashes(); err != nil {
+		return err
+	}
+	if !bseq(hash(R.Bytes()), c.hs[i-1]) {
+		return fmt.Errorf("unmatch hash from the user(%d)", i)
+	}
+	c.rs[i-1] = R
+	return nil
+}
+
+// RandomPoints returns the random points of all users to broadcast, for Muser.SetRandomPoint.
+func (c *Coordinator) RandomPoints() ([]*Point, error) {
+	for j, R := range c.rs {
+		if R == nil {
+			return nil, fmt.Errorf("not received random point from the user(%d)", j+1)
+		}
+	}
+	return append([]*Point{}, c.rs...), nil
+}
+
+// SetSign verifies and sets the sign of user(i), failing if it is already set.
+func (c *Coordinator) SetSign(i int, s []byte) error {
+	if i < 1 || c.u < i || len(s) != 32 {
+		return fmt.Errorf("illegal parameter")
+	}
+	if c.ss[i-1] != nil {
+		return fmt.Errorf("sign from the user(%d) already received", i)
+	}
+	R, err := c.sumR()
+	if err != nil {
+		return err
+	}
+	Q, neg, _, err := c.key()
+	if err != nil {
+		return err
+	}
+	e, _ := challenge(R, Q, c.m, c.tweaked)
+	// -e, or e when Q = -P + tG
+	me := sub(n, e.bigInt())
+	if neg {
+		me = e.bigInt()
+	}
+	mu := c.ka.coefficient(i - 1).bigInt()
+	if err := checkSign(i, s, me, mu, c.ps[i-1], c.rs[i-1]); err != nil {
+		return err
+	}
+	c.ss[i-1] = append([]byte{}, s...)
+	return nil
+}
+
+// Signing returns the multisignature of the signs of all users.
+func (c *Coordinator) Signing() ([]byte, error) {
+	R, err := c.sumR()
+	if err != nil {
+		return nil, err
+	}
+	Q, _, t, err := c.key()
+	if err != nil {
+		return nil, err
+	}
+	// s = Σs_j + et
+	s := big.NewInt(0)
+	for j, sj := range c.ss {
+		if sj == nil {
+			return nil, fmt.Errorf("not received sign from the user(%d)", j+1)
+		}
+		s = mod(add(s, intbs(sj)), n)
+	}
+	e, _ := challenge(R, Q, c.m, c.tweaked)
+	s = mod(add(s, mul(e.bigInt(), t.bigInt())), n)
+	sig := ll(bytes(x(R)), bytes(s))
+	// Fail if Verification(Q, m, sig) fails.
+	if !verification(Q, c.m, sig, c.tweaked) {
+		return nil, ErrSigningFault
+	}
+	return sig, nil
+}
+
+// key returns Q, whether P is negated, which is when gacc = n - 1, and the tweak tacc.
+func (c *Coordinator) key() (*Point, bool, *scalar, error) {
+	Q, err := c.Q()
+	if err != nil {
+		return nil, false, nil, err
+	}
+	var one scalar
+	neg := !c.ka.gacc.equal(one.setUint(1))
+	t := c.ka.tacc
+	// An odd Q flips the signs, see tapTweakEven.
+	if c.tweaked && !hasEvenY(Q) {
+		neg = !neg
+		t.neg(&t)
+	}
+	return Q, neg, &t, nil
+}
+
+// step returns the last step of which an input has been received:
+// 0 for none, 1 for public keys, 2 for hash values, 3 for random points and 4 for signs.
+func (c *Coordinator) step() int {
+	for j := range c.ss {
+		if c.ss[j] != nil {
+			return 4
+		}
+	}
+	for j := range c.rs {
+		if c.rs[j] != nil {
+			return 3
+		}
+	}
+	for j := range c.hs {
+		if c.hs[j] != nil {
+			return 2
+		}
+	}
+	for j := range c.ps {
+		if c.ps[j] != nil {
+			return 1
+		}
+	}
+	return 0
+}
+
+// sumR returns R = ΣR_j.
+func (c *Coordinator) sumR() (*Point, error) {
+	rs, err := c.RandomPoints()
+	if err != nil {
+		return nil, err
+	}
+	var R jacobianPoint
+	for _, r := range rs {
+		R.addPoint(r)
+	}
+	return R.point(), nil
+}
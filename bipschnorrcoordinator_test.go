@@ -0,0 +1,171 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestCoordinator(t *testing.T) {
+	coordinator(t, false, nil)
+}
+
+func TestCoordinatorTweak(t *testing.T) {
+	coordinator(t, true, nil)
+	coordinator(t, true, rndbs())
+}
+
+// coordinator runs a multisignature where the users send to and receive from the coordinator only.
+func coordinator(t *testing.T, tweak bool, root []byte) {
+	u := rndi(10) + 2
+	m := rndbs()
+	c, err := bipschnorr.NewCoordinator(u, m)
+	if err != nil {
+		t.Fatalf("NewCoordinator : %v", err)
+	}
+	if tweak {
+		c.SetTweak(root)
+	}
+	users := []*bipschnorr.Muser{}
+	for i := 1; i <= u; i++ {
+		user, err := bipschnorr.NewMultiUser(i, u, rndbi(), m)
+		if err != nil {
+			t.Fatalf("NewMultiUser : %v", err)
+		}
+		if tweak {
+			user.SetTweak(root)
+		}
+		users = append(users, user)
+	}
+
+	// Step1 : public keys, each received once and all before any hash value
+	if err := c.SetHash(1, users[0].Hash()); err == nil {
+		t.Errorf("hash value before public keys")
+	}
+	for i, user := range users {
+		if err := c.SetPublicKey(i+1, user.PublicKey()); err != nil {
+			t.Fatalf("SetPublicKey : %v", err)
+		}
+	}
+	if err := c.SetPublicKey(1, users[1].PublicKey()); err == nil {
+		t.Errorf("public key overwritten")
+	}
+	if err := c.SetTweak(nil); err == nil {
+		t.Errorf("tweak after public keys")
+	}
+	ps, err := c.PublicKeys()
+	if err != nil {
+		t.Fatalf("PublicKeys : %v", err)
+	}
+	// The broadcasts are copies, which the caller can not use to change the session.
+	ps[0] = ps[1]
+	if ps, _ = c.PublicKeys(); ps[0].Equal(ps[1]) {
+		t.Errorf("public keys changed through a broadcast")
+	}
+	for _, user := range users {
+		for j, P := range ps {
+			user.SetPublicKey(j+1, P)
+		}
+	}
+
+	// Step2 : hash values
+	for i, user := range users {
+		if _, err := c.RandomPoints(); err == nil {
+			t.Errorf("random points before hash values")
+		}
+		c.SetHash(i+1, user.Hash())
+	}
+	if err := c.SetHash(1, users[1].Hash()); err == nil {
+		t.Errorf("hash value overwritten")
+	}
+	if err := c.SetPublicKey(1, users[0].PublicKey()); err == nil {
+		t.Errorf("public key after hash values")
+	}
+	hs, err := c.Hashes()
+	if err != nil {
+		t.Fatalf("Hashes : %v", err)
+	}
+	hs[0][0] ^= 1
+	if hs, _ = c.Hashes(); !bytes.Equal(hs[0], users[0].Hash()) {
+		t.Errorf("hash values changed through a broadcast")
+	}
+	for i, user := range users {
+		for j, h := range hs {
+			if i != j {
+				user.SetHash(j+1, h)
+			}
+		}
+	}
+
+	// Step3 : random points, checked against the hash values
+	if err := c.SetRandomPoint(1, users[1%u].RandomPoint()); err == nil {
+		t.Errorf("random point of another user accepted")
+	}
+	for i, user := range users {
+		if err := c.SetRandomPoint(i+1, user.RandomPoint()); err != nil {
+			t.Fatalf("SetRandomPoint : %v", err)
+		}
+	}
+	if err := c.SetRandomPoint(1, users[0].RandomPoint()); err == nil {
+		t.Errorf("random point received twice")
+	}
+	if err := c.SetHash(1, users[0].Hash()); err == nil {
+		t.Errorf("hash value after random points")
+	}
+	rs, err := c.RandomPoints()
+	if err != nil {
+		t.Fatalf("RandomPoints : %v", err)
+	}
+	rs[0] = rs[1]
+	if rs, _ = c.RandomPoints(); rs[0].Equal(rs[1]) {
+		t.Errorf("random points changed through a broadcast")
+	}
+	for i, user := range users {
+		for j, R := range rs {
+			if i != j {
+				user.SetRandomPoint(j+1, R)
+			}
+		}
+		if err := user.CheckHash(); err != nil {
+			t.Fatalf("CheckHash : %v", err)
+		}
+	}
+
+	// Step4 : signs, checked by the coordinator, each received once
+	ss := [][]byte{}
+	for i, user := range users {
+		s, err := user.Sign()
+		if err != nil {
+			t.Fatalf("Sign : %v", err)
+		}
+		bad := append([]byte{}, s...)
+		bad[31] ^= 1
+		if err := c.SetSign(i+1, bad); err == nil || err.Error() != fmt.Sprintf("fail to check sign from the user(%d)", i+1) {
+			t.Errorf("bad sign : %v", err)
+		}
+		if err := c.SetSign(i+1, s); err != nil {
+			t.Fatalf("SetSign : %v", err)
+		}
+		ss = append(ss, s)
+	}
+	if err := c.SetSign(1, ss[0]); err == nil || err.Error() != "sign from the user(1) already received" {
+		t.Errorf("sign received twice : %v", err)
+	}
+	if err := c.SetRandomPoint(1, users[0].RandomPoint()); err == nil {
+		t.Errorf("random point after signs")
+	}
+	sig, err := c.Signing()
+	if err != nil {
+		t.Fatalf("Signing : %v", err)
+	}
+	Q, _ := users[0].Q()
+	v := bipschnorr.Verification(Q, m, sig)
+	if tweak {
+		v = bipschnorr.VerificationBIP340(Q.BytesXOnly(), m, sig)
+	}
+	if !v {
+		t.Errorf("fail verify : %x", sig)
+	}
+}
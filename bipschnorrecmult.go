@@ -0,0 +1,244 @@
+package bipschnorr
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// ecmultWindow is the wNAF window width for points that are not known in advance.
+// Precomputed bases use the wider fixedBaseWindow.
+const ecmultWindow = 5
+
+// ecmultTerm is the term kP of a multi-scalar multiplication.
+type ecmultTerm struct {
+	k scalar
+	P jacobianPoint
+}
+
+// term returns the ecmultTerm xP.
+func term(x *big.Int, P *Point) ecmultTerm {
+	var t ecmultTerm
+	t.k.setInt(x)
+	t.P = P.jacobian()
+	return t
+}
+
+// ecmult returns gG + k_1P_1 + ... + k_uP_u using Strauss' algorithm:
+// the wNAF expansions of all scalars are interleaved over one shared chain of doublings.
+// Every scalar is split with the endomorphism, so the chain is about 129 doublings long.
+// g may be nil. It runs in variable time, so every input must be public.
+func ecmult(g *scalar, terms []ecmultTerm) jacobianPoint {
+	size := 1 << (ecmultWindow - 2)
+	js := make([]jacobianPoint, len(terms)*size)
+	nafs := make([][]int8, 0, 2*len(terms)+2)
+	for i := range terms {
+		k1, k2, neg1, neg2 := terms[i].k.split()
+		nafs = append(nafs, wnaf(&k1, ecmultWindow, neg1), wnaf(&k2, ecmultWindow, neg2))
+		oddMultiples(js[i*size:(i+1)*size], &terms[i].P)
+	}
+	tbl := batchAffine(js)
+	lam := make([]affinePoint, len(tbl))
+	tbls := make([][]affinePoint, 0, cap(nafs))
+	for i := range terms {
+		for j := i * size; j < (i+1)*size; j++ {
+			lam[j].endo(&tbl[j])
+		}
+		tbls = append(tbls, tbl[i*size:(i+1)*size], lam[i*size:(i+1)*size])
+	}
+	if g != nil {
+		g1, g2, neg1, neg2 := g.split()
+		nafs = append(nafs, wnaf(&g1, fixedBaseWindow, neg1), wnaf(&g2, fixedBaseWindow, neg2))
+		odd, oddLam := gBase.oddTable()
+		tbls = append(tbls, odd, oddLam)
+	}
+	l := 0
+	for _, naf := range nafs {
+		if len(naf) > l {
+			l = len(naf)
+		}
+	}
+	var acc jacobianPoint
+	for i := l - 1; i >= 0; i-- {
+		acc.double(&acc)
+		for j, naf := range nafs {
+			if i < len(naf) {
+				acc.addDigit(naf[i], tbls[j])
+			}
+		}
+	}
+	return acc
+}
+
+// addDigit sets r = r + dP for a wNAF digit d, where tbl holds P, 3P, 5P, ...
+func (r *jacobianPoint) addDigit(d int8, tbl []affinePoint) {
+	if d > 0 {
+		r.addAffine(r, &tbl[(d-1)/2])
+	} else if d < 0 {
+		a := tbl[(-d-1)/2]
+		a.y.neg(&a.y)
+		r.addAffine(r, &a)
+	}
+}
+
+// oddMultiples sets js to P, 3P, 5P, ...
+func oddMultiples(js []jacobianPoint, P *jacobianPoint) {
+	var P2 jacobianPoint
+	P2.double(P)
+	js[0] = *P
+	for i := 1; i < len(js); i++ {
+		js[i].add(&js[i-1], &P2)
+	}
+}
+
+// wnaf returns the width-w non-adjacent form of k, or of -k if neg is true, least significant digit first.
+// Every nonzero digit is odd and below 2^(w-1) in absolute value, and is followed by at least w-1 zeros.
+func wnaf(k *scalar, w uint, neg bool) []int8 {
+	t := [5]uint64{k[0], k[1], k[2], k[3], 0}
+	naf := make([]int8, 0, 258)
+	for t[0]|t[1]|t[2]|t[3]|t[4] != 0 {
+		var d int64
+		if t[0]&1 == 1 {
+			d = int64(t[0] & (1<<w - 1))
+			if d >= 1<<(w-1) {
+				d -= 1 << w
+			}
+			// t = t - d, which clears the low w bits.
+			var c uint64
+			if d > 0 {
+				t[0], c = bits.Sub64(t[0], uint64(d), 0)
+				for i := 1; i < 5 && c != 0; i++ {
+					t[i], c = bits.Sub64(t[i], 0, c)
+				}
+			} else {
+				t[0], c = bits.Add64(t[0], uint64(-d), 0)
+				for i := 1; i < 5 && c != 0; i++ {
+					t[i], c = bits.Add64(t[i], 0, c)
+				}
+			}
+		}
+		if neg {
+			d = -d
+		}
+		naf = append(naf, int8(d))
+		for i := 0; i < 4; i++ {
+			t[i] = t[i]>>1 | t[i+1]<<63
+		}
+		t[4] >>= 1
+	}
+	return naf
+}
+
+// pippengerMin is the number of terms from which ecmultMulti switches from Strauss' algorithm to pippenger.
+const pippengerMin = 64
+
+// ecmultMulti returns gG + k_1P_1 + ... + k_uP_u with whichever of ecmult and pippenger is faster for u terms.
+// g may be nil. It runs in variable time, so every input must be public.
+func ecmultMulti(g *scalar, terms []ecmultTerm) jacobianPoint {
+	if len(terms) < pippengerMin {
+		return ecmult(g, terms)
+	}
+	if g != nil {
+		// Copy, so the G term never lands in spare capacity of the caller's slice.
+		terms = append(append(make([]ecmultTerm, 0, len(terms)+1), terms...), ecmultTerm{*g, G.jacobian()})
+	}
+	return pippenger(terms)
+}
+
+// pippenger returns k_1P_1 + ... + k_uP_u using the bucket method:
+// every scalar is split with the endomorphism and recoded into signed c bit digits,
+// then for each window the points are added to the bucket of their digit and the buckets are summed with their weights.
+// It runs in variable time, so every input must be public.
+func pippenger(terms []ecmultTerm) jacobianPoint {
+	js := make([]jacobianPoint, len(terms))
+	ks := make([]scalar, 2*len(terms))
+	negs := make([]bool, 2*len(terms))
+	for i := range terms {
+		ks[2*i], ks[2*i+1], negs[2*i], negs[2*i+1] = terms[i].k.split()
+		js[i] = terms[i].P
+	}
+	as := batchAffine(js)
+	ps := make([]affinePoint, 2*len(terms))
+	for i := range as {
+		ps[2*i] = as[i]
+		ps[2*i+1].endo(&as[i])
+	}
+	for i := range ps {
+		if negs[i] {
+			ps[i].y.neg(&ps[i].y)
+		}
+	}
+	c := pippengerWindow(len(terms))
+	// The split scalars are below 2^129, and the last carry of the recoding needs one more bit.
+	nw := int((130 + c) / c)
+	ds := make([]int32, nw*len(ps))
+	for i := range ps {
+		ks[i].signedDigits(c, ds[i*nw:(i+1)*nw])
+	}
+	buckets := make([]jacobianPoint, 1<<(c-1))
+	var acc jacobianPoint
+	var t affinePoint
+	for w := nw - 1; w >= 0; w-- {
+		for i := uint(0); i < c; i++ {
+			acc.double(&acc)
+		}
+		for b := range buckets {
+			buckets[b] = jacobianPoint{}
+		}
+		for i := range ps {
+			d := ds[i*nw+w]
+			switch {
+			case d > 0:
+				buckets[d-1].addAffine(&buckets[d-1], &ps[i])
+			case d < 0:
+				t.x, t.inf = ps[i].x, ps[i].inf
+				t.y.neg(&ps[i].y)
+				buckets[-d-1].addAffine(&buckets[-d-1], &t)
+			}
+		}
+		// Σ d * bucket_d as a running sum of running sums.
+		var sum, total jacobianPoint
+		for b := len(buckets) - 1; b >= 0; b-- {
+			sum.add(&sum, &buckets[b])
+			total.add(&total, &sum)
+		}
+		acc.add(&acc, &total)
+	}
+	return acc
+}
+
+// pippengerWindow returns the bucket window width for u terms.
+func pippengerWindow(u int) uint {
+	limits := []int{4, 20, 57, 136, 235, 1260, 4420, 7880, 16050}
+	for i, l := range limits {
+		if u <= l {
+			return uint(i + 2)
+		}
+	}
+	return 11
+}
+
+// signedDigits writes r, which must be below 2^129, as len(ds) signed c bit digits in [-2^(c-1), 2^(c-1)), least significant first.
+func (r *scalar) signedDigits(c uint, ds []int32) {
+	var carry int32
+	for w := range ds {
+		d := int32(r.bits(uint(w)*c, c)) + carry
+		carry = 0
+		if d >= 1<<(c-1) {
+			d -= 1 << c
+			carry = 1
+		}
+		ds[w] = d
+	}
+}
+
+// bits returns the c bits of r starting at bit off.
+func (r *scalar) bits(off, c uint) uint64 {
+	if off >= 256 {
+		return 0
+	}
+	v := r[off/64] >> (off % 64)
+	if off%64+c > 64 && off/64 < 3 {
+		v |= r[off/64+1] << (64 - off%64)
+	}
+	return v & (1<<c - 1)
+}
@@ -0,0 +1,295 @@
+package bipschnorr
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// fieldVal is an integer modulo p held in four 64-bit limbs, least significant first.
+// A fieldVal is always fully reduced and every operation runs in time independent of its value.
+type fieldVal [4]uint64
+
+// fieldP is p in limbs.
+var fieldP = [4]uint64{0xFFFFFFFEFFFFFC2F, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF}
+
+// fieldC is 2^256 - p.
+const fieldC = 0x1000003D1
+
+// fieldOne is 1 as a field element.
+var fieldOne = fieldVal{1, 0, 0, 0}
+
+// fieldSqrtExp is (p + 1) / 4, fieldJacobiExp is (p - 1) / 2 and fieldInvExp is p - 2.
+var (
+	fieldSqrtExp   = [4]uint64{0xFFFFFFFFBFFFFF0C, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0x3FFFFFFFFFFFFFFF}
+	fieldJacobiExp = [4]uint64{0xFFFFFFFF7FFFFE17, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0x7FFFFFFFFFFFFFFF}
+	fieldInvExp    = [4]uint64{0xFFFFFFFEFFFFFC2D, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF}
+)
+
+// setBytes sets r to the 32 byte big endian integer b mod p and reports whether b was below p.
+func (r *fieldVal) setBytes(b []byte) bool {
+	var t [4]uint64
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			t[3-i] = t[3-i]<<8 | uint64(b[i*8+j])
+		}
+	}
+	u, borrow := sub256(&t, &fieldP)
+	*r = fieldVal(select256(borrow-1, &u, &t))
+	return borrow == 1
+}
+
+// setInt sets r to x mod p.
+func (r *fieldVal) setInt(x *big.Int) *fieldVal {
+	if x.Sign() < 0 || x.Cmp(p) >= 0 {
+		x = mod(x, p)
+	}
+	r.setBytes(bytes(x))
+	return r
+}
+
+// putBytes writes the 32 byte big endian encoding of r to b.
+func (r *fieldVal) putBytes(b []byte) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[i*8+j] = byte(r[3-i] >> uint(56-8*j))
+		}
+	}
+}
+
+// bigInt returns r as a big.Int.
+func (r *fieldVal) bigInt() *big.Int {
+	bs := make([]byte, 32)
+	r.putBytes(bs)
+	return intbs(bs)
+}
+
+// isZero returns whether r is zero.
+func (r *fieldVal) isZero() bool {
+	return r[0]|r[1]|r[2]|r[3] == 0
+}
+
+// isOdd returns whether r is odd.
+func (r *fieldVal) isOdd() bool {
+	return r[0]&1 == 1
+}
+
+// equal returns whether r and a are the same element.
+func (r *fieldVal) equal(a *fieldVal) bool {
+	return (r[0]^a[0])|(r[1]^a[1])|(r[2]^a[2])|(r[3]^a[3]) == 0
+}
+
+// add sets r = a + b.
+func (r *fieldVal) add(a, b *fieldVal) *fieldVal {
+	var c, c1, c2 uint64
+	s0, c := bits.Add64(a[0], b[0], 0)
+	s1, c := bits.Add64(a[1], b[1], c)
+	s2, c := bits.Add64(a[2], b[2], c)
+	s3, c1 := bits.Add64(a[3], b[3], c)
+	// s + 2^256 - p overflows exactly when a + b >= p.
+	t0, c := bits.Add64(s0, fieldC, 0)
+	t1, c := bits.Add64(s1, 0, c)
+	t2, c := bits.Add64(s2, 0, c)
+	t3, c2 := bits.Add64(s3, 0, c)
+	r.set(-(c1 | c2), t0, t1, t2, t3, s0, s1, s2, s3)
+	return r
+}
+
+// sub sets r = a - b.
+func (r *fieldVal) sub(a, b *fieldVal) *fieldVal {
+	var c uint64
+	s0, c := bits.Sub64(a[0], b[0], 0)
+	s1, c := bits.Sub64(a[1], b[1], c)
+	s2, c := bits.Sub64(a[2], b[2], c)
+	s3, c := bits.Sub64(a[3], b[3], c)
+	m := -c
+	r[0], c = bits.Add64(s0, fieldP[0]&m, 0)
+	r[1], c = bits.Add64(s1, fieldP[1]&m, c)
+	r[2], c = bits.Add64(s2, fieldP[2]&m, c)
+	r[3], _ = bits.Add64(s3, fieldP[3]&m, c)
+	return r
+}
+
+// neg sets r = -a.
+func (r *fieldVal) neg(a *fieldVal) *fieldVal {
+	return r.sub(&fieldVal{}, a)
+}
+
+// mul sets r = a * b.
+func (r *fieldVal) mul(a, b *fieldVal) *fieldVal {
+	r.reduce(mul512((*[4]uint64)(a), (*[4]uint64)(b)))
+	return r
+}
+
+// sqr sets r = a * a.
+func (r *fieldVal) sqr(a *fieldVal) *fieldVal {
+	return r.mul(a, a)
+}
+
+// mulInt sets r = a * x for a small integer x.
+func (r *fieldVal) mulInt(a *fieldVal, x uint64) *fieldVal {
+	return r.mul(a, &fieldVal{x, 0, 0, 0})
+}
+
+// reduce sets r to the 512 bit integer t0 + t1 2^64 + ... + t7 2^448 mod p, using 2^256 = 2^256 - p (mod p).
+func (r *fieldVal) reduce(t0, t1, t2, t3, t4, t5, t6, t7 uint64) {
+	var k, c uint64
+	k, s0 := madd(t4, fieldC, t0, 0)
+	k, s1 := madd(t5, fieldC, t1, k)
+	k, s2 := madd(t6, fieldC, t2, k)
+	k, s3 := madd(t7, fieldC, t3, k)
+	hi, lo := bits.Mul64(k, fieldC)
+	s0, c = bits.Add64(s0, lo, 0)
+	s1, c = bits.Add64(s1, hi, c)
+	s2, c = bits.Add64(s2, 0, c)
+	s3, c = bits.Add64(s3, 0, c)
+	// A final wrap leaves s small, so adding 2^256 - p once more cannot carry.
+	s0, c = bits.Add64(s0, c*fieldC, 0)
+	s1, c = bits.Add64(s1, 0, c)
+	s2, c = bits.Add64(s2, 0, c)
+	s3, _ = bits.Add64(s3, 0, c)
+	u0, c := bits.Sub64(s0, fieldP[0], 0)
+	u1, c := bits.Sub64(s1, fieldP[1], c)
+	u2, c := bits.Sub64(s2, fieldP[2], c)
+	u3, c := bits.Sub64(s3, fieldP[3], c)
+	r.set(c-1, u0, u1, u2, u3, s0, s1, s2, s3)
+}
+
+// set sets r to the limbs a if mask is all ones and to the limbs b if mask is zero.
+func (r *fieldVal) set(mask, a0, a1, a2, a3, b0, b1, b2, b3 uint64) {
+	r[0] = b0 ^ (mask & (a0 ^ b0))
+	r[1] = b1 ^ (mask & (a1 ^ b1))
+	r[2] = b2 ^ (mask & (a2 ^ b2))
+	r[3] = b3 ^ (mask & (a3 ^ b3))
+}
+
+// pow sets r = a^e. The exponent is public, the base is not.
+func (r *fieldVal) pow(a *fieldVal, e *[4]uint64) *fieldVal {
+	var tbl [16]fieldVal
+	tbl[0] = fieldOne
+	tbl[1] = *a
+	for i := 2; i < 16; i++ {
+		tbl[i].mul(&tbl[i-1], a)
+	}
+	z := fieldOne
+	for i := 63; i >= 0; i-- {
+		z.sqr(&z)
+		z.sqr(&z)
+		z.sqr(&z)
+		z.sqr(&z)
+		z.mul(&z, &tbl[(e[i/16]>>uint(4*(i%16)))&0xF])
+	}
+	*r = z
+	return r
+}
+
+// inv sets r = a^-1 (Fermat's little theorem); the inverse of zero is zero.
+func (r *fieldVal) inv(a *fieldVal) *fieldVal {
+	return r.pow(a, &fieldInvExp)
+}
+
+// sqrt sets r to the square root of a which is itself a quadratic residue and reports whether it exists.
+func (r *fieldVal) sqrt(a *fieldVal) bool {
+	var s, c fieldVal
+	s.pow(a, &fieldSqrtExp)
+	ok := c.sqr(&s).equal(a)
+	*r = s
+	return ok
+}
+
+// jacobi returns the Jacobi symbol of a / p, 1, -1 or 0.
+func (r *fieldVal) jacobi() int {
+	var j fieldVal
+	j.pow(r, &fieldJacobiExp)
+	if j.isZero() {
+		return 0
+	}
+	if j.equal(&fieldOne) {
+		return 1
+	}
+	return -1
+}
+
+// cmov sets r = a if flag is true, without branching on flag.
+func (r *fieldVal) cmov(a *fieldVal, flag bool) {
+	r.set(-b2u(flag), a[0], a[1], a[2], a[3], r[0], r[1], r[2], r[3])
+}
+
+// 256 bit limb arithmetic shared by fieldVal and scalar.
+
+// add256 returns a + b and the carry out.
+func add256(a, b *[4]uint64) ([4]uint64, uint64) {
+	var s [4]uint64
+	var c uint64
+	s[0], c = bits.Add64(a[0], b[0], 0)
+	s[1], c = bits.Add64(a[1], b[1], c)
+	s[2], c = bits.Add64(a[2], b[2], c)
+	s[3], c = bits.Add64(a[3], b[3], c)
+	return s, c
+}
+
+// sub256 returns a - b and the borrow out.
+func sub256(a, b *[4]uint64) ([4]uint64, uint64) {
+	var s [4]uint64
+	var c uint64
+	s[0], c = bits.Sub64(a[0], b[0], 0)
+	s[1], c = bits.Sub64(a[1], b[1], c)
+	s[2], c = bits.Sub64(a[2], b[2], c)
+	s[3], c = bits.Sub64(a[3], b[3], c)
+	return s, c
+}
+
+// select256 returns a if mask is all ones and b if mask is zero.
+func select256(mask uint64, a, b *[4]uint64) [4]uint64 {
+	return [4]uint64{
+		b[0] ^ (mask & (a[0] ^ b[0])),
+		b[1] ^ (mask & (a[1] ^ b[1])),
+		b[2] ^ (mask & (a[2] ^ b[2])),
+		b[3] ^ (mask & (a[3] ^ b[3])),
+	}
+}
+
+// mul512 returns the limbs of the full 512 bit product a * b.
+func mul512(a, b *[4]uint64) (t0, t1, t2, t3, t4, t5, t6, t7 uint64) {
+	var c uint64
+	c, t0 = madd(a[0], b[0], 0, 0)
+	c, t1 = madd(a[0], b[1], 0, c)
+	c, t2 = madd(a[0], b[2], 0, c)
+	c, t3 = madd(a[0], b[3], 0, c)
+	t4 = c
+	c, t1 = madd(a[1], b[0], t1, 0)
+	c, t2 = madd(a[1], b[1], t2, c)
+	c, t3 = madd(a[1], b[2], t3, c)
+	c, t4 = madd(a[1], b[3], t4, c)
+	t5 = c
+	c, t2 = madd(a[2], b[0], t2, 0)
+	c, t3 = madd(a[2], b[1], t3, c)
+	c, t4 = madd(a[2], b[2], t4, c)
+	c, t5 = madd(a[2], b[3], t5, c)
+	t6 = c
+	c, t3 = madd(a[3], b[0], t3, 0)
+	c, t4 = madd(a[3], b[1], t4, c)
+	c, t5 = madd(a[3], b[2], t5, c)
+	c, t6 = madd(a[3], b[3], t6, c)
+	t7 = c
+	return
+}
+
+// madd returns a * b + c + d as a 128 bit value.
+func madd(a, b, c, d uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(a, b)
+	var cc uint64
+	lo, cc = bits.Add64(lo, c, 0)
+	hi += cc
+	lo, cc = bits.Add64(lo, d, 0)
+	hi += cc
+	return hi, lo
+}
+
+// b2u returns 1 for true and 0 for false.
+func b2u(b bool) uint64 {
+	var u uint64
+	if b {
+		u = 1
+	}
+	return u
+}
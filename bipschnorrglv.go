@@ -0,0 +1,106 @@
+package bipschnorr
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// secp256k1 has the endomorphism φ(x, y) = (βx, y), which acts on every point as multiplication by λ.
+// Splitting k into k1 + k2λ with k1, k2 of about 128 bits lets kP = k1P + k2φ(P) be computed with half the doublings.
+// See "Faster Point Multiplication on Elliptic Curves with Efficient Endomorphisms" (Gallant, Lambert, Vanstone).
+
+// glvLambda is λ, a cube root of unity modulo n, and glvBeta is β, the matching cube root of unity modulo p.
+var (
+	glvLambda = hexScalar("5363AD4CC05C30E0A5261C028812645A122E22EA20816678DF02967C1B23BD72")
+	glvBeta   = hexField("7AE96A2B657C07106E64479EAC3434E99CF0497512F58995C1396C28719501EE")
+)
+
+// The short lattice basis (a1, b1), (a2, b2) of {(x, y) | x + yλ = 0 mod n} has b2 = a1.
+// glvMinusB1 and glvMinusB2 are -b1 and -b2 mod n, and glvG1 and glvG2 are round(2^384 b2 / n) and round(2^384 (-b1) / n).
+var (
+	glvA1      = hexBig("3086D221A7D46BCDE86C90E49284EB15")
+	glvMinusB1 = hexScalar("E4437ED6010E88286F547FA90ABFE4C3")
+	glvMinusB2 = *new(scalar).setInt(new(big.Int).Neg(glvA1))
+	glvG1      = glvRound(glvA1)
+	glvG2      = glvRound(hexBig("E4437ED6010E88286F547FA90ABFE4C3"))
+)
+
+// split returns k1 and k2 with k = k1 + k2λ (mod n) as magnitudes below 2^129 and signs.
+// It runs in time independent of k.
+func (k *scalar) split() (k1, k2 scalar, neg1, neg2 bool) {
+	var c1, c2 scalar
+	c1.mulShift384(k, &glvG1)
+	c2.mulShift384(k, &glvG2)
+	c1.mul(&c1, &glvMinusB1)
+	c2.mul(&c2, &glvMinusB2)
+	k2.add(&c1, &c2)
+	k1.mul(&k2, &glvLambda).sub(k, &k1)
+	neg1 = k1.isHigh()
+	neg2 = k2.isHigh()
+	var t scalar
+	k1.cmov(t.neg(&k1), neg1)
+	k2.cmov(t.neg(&k2), neg2)
+	return k1, k2, neg1, neg2
+}
+
+// mulShift384 sets r = round(a * b / 2^384).
+func (r *scalar) mulShift384(a, b *scalar) *scalar {
+	_, _, _, _, _, t5, t6, t7 := mul512((*[4]uint64)(a), (*[4]uint64)(b))
+	var c uint64
+	r[0], c = bits.Add64(t6, t5>>63, 0)
+	r[1], c = bits.Add64(t7, 0, c)
+	r[2], r[3] = c, 0
+	return r
+}
+
+// isHigh returns whether r is above n / 2.
+func (r *scalar) isHigh() bool {
+	_, borrow := sub256(&scalarHalfN, (*[4]uint64)(r))
+	return borrow == 1
+}
+
+// scalarHalfN is (n - 1) / 2.
+var scalarHalfN = [4]uint64{0xDFE92F46681B20A0, 0x5D576E7357A4501D, 0xFFFFFFFFFFFFFFFF, 0x7FFFFFFFFFFFFFFF}
+
+// endo sets r = φ(a) = λa.
+func (r *affinePoint) endo(a *affinePoint) *affinePoint {
+	*r = *a
+	r.x.mul(&a.x, &glvBeta)
+	return r
+}
+
+// endo sets r = φ(a) = λa.
+func (r *jacobianPoint) endo(a *jacobianPoint) *jacobianPoint {
+	*r = *a
+	r.x.mul(&a.x, &glvBeta)
+	return r
+}
+
+// glvRound returns round(2^384 x / n).
+func glvRound(x *big.Int) scalar {
+	var r scalar
+	q := new(big.Int).Lsh(x, 384)
+	q.Add(q, new(big.Int).Rsh(n, 1))
+	r.setInt(q.Quo(q, n))
+	return r
+}
+
+// hexBig returns the integer with hexadecimal digits h.
+func hexBig(h string) *big.Int {
+	x, _ := new(big.Int).SetString(h, 16)
+	return x
+}
+
+// hexScalar returns the scalar with hexadecimal digits h.
+func hexScalar(h string) scalar {
+	var r scalar
+	r.setInt(hexBig(h))
+	return r
+}
+
+// hexField returns the field element with hexadecimal digits h.
+func hexField(h string) fieldVal {
+	var r fieldVal
+	r.setInt(hexBig(h))
+	return r
+}
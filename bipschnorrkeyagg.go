@@ -0,0 +1,180 @@
+package bipschnorr
+
+// MuSig2 key aggregation.
+// https://github.com/bitcoin/bips/blob/master/bip-0327.mediawiki
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// KeyAggContext is the result of KeyAgg: the aggregate key Q and the coefficient of each public key,
+// with the tweaks applied by ApplyTweak. It holds no secrets, so watch-only services can keep one.
+type KeyAggContext struct {
+	pks  [][]byte // plain public keys bytes(P_i)
+	as   []scalar // coefficients a_i
+	q    *Point   // aggregate key
+	gacc scalar   // accumulated sign of the tweaks
+	tacc scalar   // accumulated tweak
+}
+
+// KeyAgg is
+// Input:
+// The public keys P_1..u: points on the curve, in the order every signer uses.
+// It returns the context of the aggregate key Q = a_1P_1 + ... + a_uP_u.
+func KeyAgg(pubkeys []*Point) (*KeyAggContext, error) {
+	if len(pubkeys) == 0 {
+		return nil, fmt.Errorf("no public keys")
+	}
+	ctx := &KeyAggContext{}
+	for i, P := range pubkeys {
+		if !oncurve(P) || infinite(P) {
+			return nil, fmt.Errorf("public key %d : %v", i, ErrPubKeyNotOnCurve)
+		}
+		ctx.pks = append(ctx.pks, P.Bytes())
+	}
+	// Let L = hash_KeyAgg list(pk_1 || pk_2 || ... || pk_u).
+	L := hashTag("KeyAgg list", ll(ctx.pks...))
+	// Let pk2 = GetSecondKey(pk_1..u), the first key not equal to pk_1, or 33 zero bytes.
+	pk2 := make([]byte, 33)
+	for _, pk := range ctx.pks[1:] {
+		if !bseq(pk, ctx.pks[0]) {
+			pk2 = pk
+			break
+		}
+	}
+	terms := make([]ecmultTerm, len(pubkeys))
+	ctx.as = make([]scalar, len(pubkeys))
+	for i, pk := range ctx.pks {
+		// Let a_i = 1 if pk_i = pk2, otherwise a_i = int(hash_KeyAgg coefficient(L || pk_i)) mod n.
+		if bseq(pk, pk2) {
+			ctx.as[i].setUint(1)
+		} else {
+			ctx.as[i].setBytes(hashTag("KeyAgg coefficient", ll(L, pk)))
+		}
+		terms[i].k = ctx.as[i]
+		terms[i].P = pubkeys[i].jacobian()
+	}
+	// Let Q = a_1P_1 + a_2P_2 + ... + a_uP_u; fail if infinite(Q).
+	J := ecmult(nil, terms)
+	Q := J.point()
+	if infinite(Q) {
+		return nil, fmt.Errorf("aggregate key is infinite")
+	}
+	// Let gacc = 1 and tacc = 0.
+	ctx.q = Q
+	ctx.gacc.setUint(1)
+	return ctx, nil
+}
+
+// KeySort returns the public keys sorted by bytes(P), which gives every signer the same order for KeyAgg.
+func KeySort(pubkeys []*Point) []*Point {
+	ps := append([]*Point{}, pubkeys...)
+	sort.SliceStable(ps, func(i, j int) bool {
+		return string(ps[i].Bytes()) < string(ps[j].Bytes())
+	})
+	return ps
+}
+
+// Q returns the aggregate key.
+func (ctx *KeyAggContext) Q() *Point {
+	return ctx.q
+}
+
+// PublicKeys returns the aggregated public keys in order.
+func (ctx *KeyAggContext) PublicKeys() []*Point {
+	ps := []*Point{}
+	for _, pk := range ctx.pks {
+		P, _ := ParsePoint(pk)
+		ps = append(ps, P)
+	}
+	return ps
+}
+
+// Coefficient returns the coefficient a of the public key P, which the signer of P multiplies its secret key by.
+func (ctx *KeyAggContext) Coefficient(P *Point) (*big.Int, error) {
+	if !oncurve(P) {
+		return nil, ErrPubKeyNotOnCurve
+	}
+	a, err := ctx.coefficientOf(P.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return a.bigInt(), nil
+}
+
+// Verification returns whether sig is a signature of m for Q, such as a multisignature of Muser.
+func (ctx *KeyAggContext) Verification(m []byte, sig []byte) bool {
+	return Verification(ctx.q, m, sig)
+}
+
+// VerificationBIP340 returns whether sig is a BIP340 signature of m for PublicKey(), such as one of a MuSig2 Session.
+func (ctx *KeyAggContext) VerificationBIP340(m []byte, sig []byte) bool {
+	return VerificationBIP340(ctx.PublicKey(), m, sig)
+}
+
+// PublicKey returns the 32 byte X coordinate of the aggregate key, which BIP340 verifies signatures with.
+func (ctx *KeyAggContext) PublicKey() []byte {
+	return ctx.q.BytesXOnly()
+}
+
+// ApplyTweak is
+// Input:
+// The tweak: a 32 byte array
+// Whether the tweak is x-only, as the Taproot tweak of BIP341 is
+// It sets the aggregate key Q to gQ + tG, where g = n - 1 for an x-only tweak of a Q with an odd Y coordinate and g = 1 otherwise.
+func (ctx *KeyAggContext) ApplyTweak(tweak []byte, xonly bool) error {
+	if len(tweak) != 32 {
+		return fmt.Errorf("tweak must be 32 bytes, got %d", len(tweak))
+	}
+	// Let t = int(tweak); fail if t ≥ n.
+	var t scalar
+	if !t.setBytes(tweak) {
+		return fmt.Errorf("tweak not below n")
+	}
+	// Let g = 1 if is_xonly_t is false or has_even_y(Q), otherwise let g = n - 1.
+	neg := xonly && !hasEvenY(ctx.q)
+	// Let Q' = g⋅Q + t⋅G; fail if is_infinite(Q').
+	J := ctx.q.jacobian()
+	if neg {
+		J.neg(&J)
+	}
+	J = ecmult(&t, []ecmultTerm{{k: scalar{1}, P: J}})
+	Q := J.point()
+	if infinite(Q) {
+		return fmt.Errorf("tweaked key is infinite")
+	}
+	// Let gacc' = g⋅gacc mod n and tacc' = t + g⋅tacc mod n.
+	if neg {
+		ctx.gacc.neg(&ctx.gacc)
+		ctx.tacc.neg(&ctx.tacc)
+	}
+	ctx.tacc.add(&ctx.tacc, &t)
+	ctx.q = Q
+	return nil
+}
+
+// TaprootTweak applies the x-only tweak of BIP341, so the aggregate key becomes TweakPublicKey(Q, merkleRoot).
+func (ctx *KeyAggContext) TaprootTweak(merkleRoot []byte) error {
+	t, err := tapTweakHash(ctx.q, merkleRoot)
+	if err != nil {
+		return err
+	}
+	return ctx.ApplyTweak(t.bytes(), true)
+}
+
+// coefficient returns a_i of the (i+1)-th public key.
+func (ctx *KeyAggContext) coefficient(i int) *scalar {
+	return &ctx.as[i]
+}
+
+// coefficientOf returns the coefficient of the plain public key pk, failing if pk was not aggregated.
+func (ctx *KeyAggContext) coefficientOf(pk []byte) (*scalar, error) {
+	for i := range ctx.pks {
+		if bseq(ctx.pks[i], pk) {
+			return &ctx.as[i], nil
+		}
+	}
+	return nil, fmt.Errorf("public key not aggregated : %x", pk)
+}
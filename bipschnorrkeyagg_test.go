@@ -0,0 +1,106 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestKeyAgg(t *testing.T) {
+	// BIP327 key_agg_vectors.json
+	var pks []*bipschnorr.Point
+	for _, s := range []string{
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"03DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"023590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66",
+	} {
+		bs, _ := hex.DecodeString(s)
+		P, err := bipschnorr.ParsePoint(bs)
+		if err != nil {
+			t.Fatalf("ParsePoint : %v", err)
+		}
+		pks = append(pks, P)
+	}
+	for _, v := range []struct {
+		idx      []int
+		expected string
+	}{
+		{[]int{0, 1, 2}, "90539EEDE565F5D054F32CC0C220126889ED1E5D193BAF15AEF344FE59D4610C"},
+		{[]int{2, 1, 0}, "6204DE8B083426DC6EAF9502D27024D53FC826BF7D2012148A0575435DF54B2B"},
+		{[]int{0, 0, 0}, "B436E3BAD62B8CD409969A224731C193D051162D8C5AE8B109306127DA3AA935"},
+		{[]int{0, 0, 1, 1}, "69BC22BFA5D106306E48A20679DE1D7389386124D07571D0D872686028C26A3E"},
+	} {
+		ps := []*bipschnorr.Point{}
+		for _, i := range v.idx {
+			ps = append(ps, pks[i])
+		}
+		ctx, err := bipschnorr.KeyAgg(ps)
+		if err != nil {
+			t.Fatalf("KeyAgg %v : %v", v.idx, err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(ctx.PublicKey()), v.expected) {
+			t.Errorf("no match KeyAgg %v : %x", v.idx, ctx.PublicKey())
+		}
+	}
+}
+
+func TestKeySort(t *testing.T) {
+	ps := []*bipschnorr.Point{}
+	for i := 0; i < 8; i++ {
+		ps = append(ps, bipschnorr.NewPoint(rndbi()))
+	}
+	ps = append(ps, ps[3])
+	sorted := bipschnorr.KeySort(ps)
+	if len(sorted) != len(ps) {
+		t.Fatalf("no match length : %d", len(sorted))
+	}
+	for i := 1; i < len(sorted); i++ {
+		if hex.EncodeToString(sorted[i-1].Bytes()) > hex.EncodeToString(sorted[i].Bytes()) {
+			t.Errorf("not sorted at %d", i)
+		}
+	}
+	if _, err := bipschnorr.KeyAgg([]*bipschnorr.Point{ps[0], nil}); err == nil {
+		t.Errorf("KeyAgg accepted an invalid public key")
+	}
+
+	// Muser aggregates the public keys in the order of the user indexes.
+	m := rndbs()
+	users := []*bipschnorr.Muser{}
+	pks := []*bipschnorr.Point{}
+	for i := 0; i < 3; i++ {
+		user, _ := bipschnorr.NewMultiUser(i+1, 3, rndbi(), m)
+		users = append(users, user)
+		pks = append(pks, user.PublicKey())
+	}
+	for _, user := range users {
+		for j, P := range pks {
+			user.SetPublicKey(j+1, P)
+		}
+	}
+	ctx, _ := bipschnorr.KeyAgg(pks)
+	for _, user := range users {
+		if P, err := user.P(); err != nil || !P.Equal(ctx.Q()) {
+			t.Errorf("no match Muser P : %v", err)
+		}
+	}
+	// The coefficients give the aggregate key.
+	Q := &bipschnorr.Point{}
+	for i, P := range ctx.PublicKeys() {
+		if !P.Equal(pks[i]) {
+			t.Errorf("no match PublicKeys %d", i)
+		}
+		a, err := ctx.Coefficient(P)
+		if err != nil {
+			t.Fatalf("Coefficient : %v", err)
+		}
+		Q.Add(Q, new(bipschnorr.Point).ScalarMult(bipschnorr.NewScalar(a), P))
+	}
+	if !Q.Equal(ctx.Q()) {
+		t.Errorf("no match Q : %x", Q.Bytes())
+	}
+	if _, err := ctx.Coefficient(bipschnorr.NewPoint(rndbi())); err == nil {
+		t.Errorf("Coefficient of a key not aggregated")
+	}
+}
@@ -0,0 +1,376 @@
+package bipschnorr
+
+import (
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+)
+
+// PrivateKey is a secret key d: an integer in the range 1..n-1.
+type PrivateKey struct {
+	d scalar
+}
+
+// NewPrivateKey returns the private key for d.
+func NewPrivateKey(d *big.Int) (*PrivateKey, error) {
+	if d == nil || d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("private key out of range 1..n-1")
+	}
+	k := &PrivateKey{}
+	k.d.setInt(d)
+	return k, nil
+}
+
+// GenerateKey returns a private key drawn uniformly from 1..n-1 with randomness read from rand.
+// 32 bytes are read and rejected until they encode an integer in range, so a deterministic rand gives a deterministic key.
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	k := &PrivateKey{}
+	d, err := randScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	k.d = d
+	return k, nil
+}
+
+// randScalar returns a scalar drawn uniformly from 1..n-1 with randomness read from rand.
+func randScalar(rand io.Reader) (scalar, error) {
+	var k scalar
+	bs := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(rand, bs); err != nil {
+			return k, fmt.Errorf("reading random data : %v", err)
+		}
+		// n is close to 2^256, so a retry is needed with probability about 2^-128.
+		if k.setBytes(bs) && !k.isZero() {
+			return k, nil
+		}
+	}
+}
+
+// ParsePrivateKey parses the 32 byte encoding bytes(d) of a private key.
+func ParsePrivateKey(bs []byte) (*PrivateKey, error) {
+	if len(bs) != 32 {
+		return nil, fmt.Errorf("private key must be 32 bytes, got %d", len(bs))
+	}
+	k := &PrivateKey{}
+	if !k.d.setBytes(bs) || k.d.isZero() {
+		return nil, fmt.Errorf("private key out of range 1..n-1")
+	}
+	return k, nil
+}
+
+// D returns the secret key d.
+func (k *PrivateKey) D() *big.Int {
+	return k.d.bigInt()
+}
+
+// PublicKey returns the public key dG.
+func (k *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{scalarBaseMul(&k.d)}
+}
+
+// Public implements crypto.Signer, returning a *PublicKey.
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return k.PublicKey()
+}
+
+// Sign implements crypto.Signer. The 32 byte digest is signed as the message m.
+// The nonce is masked with auxiliary random data read from rand (SigningWithRand)
+// unless opts is a *SignerOpts asking for a deterministic nonce (Signing). rand must not be nil otherwise.
+func (k *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if len(digest) != 32 {
+		return nil, ErrMessageLength
+	}
+	if o, ok := opts.(*SignerOpts); ok && o.Deterministic {
+		return signScalar(&k.d, digest, DraftNonce{})
+	}
+	if rand == nil {
+		return nil, fmt.Errorf("no random data for the auxiliary nonce; use SignerOpts.Deterministic")
+	}
+	a, err := readAux(rand)
+	if err != nil {
+		return nil, err
+	}
+	return signScalar(&k.d, digest, a)
+}
+
+// SignerOpts are the options of PrivateKey.Sign.
+type SignerOpts struct {
+	// Deterministic selects the nonce of Signing, which depends on the key and the message only.
+	Deterministic bool
+}
+
+// HashFunc implements crypto.SignerOpts. The digest is signed as is, so it returns zero.
+func (o *SignerOpts) HashFunc() crypto.Hash {
+	return 0
+}
+
+// Bytes returns bytes(d).
+func (k *PrivateKey) Bytes() []byte {
+	return k.d.bytes()
+}
+
+// String implements fmt.Stringer without revealing d; use Bytes or MarshalText for the encoding.
+func (k PrivateKey) String() string {
+	return redacted
+}
+
+// GoString implements fmt.GoStringer without revealing d.
+func (k PrivateKey) GoString() string {
+	return "bipschnorr.PrivateKey{" + redacted + "}"
+}
+
+// Format implements fmt.Formatter without revealing d with any verb.
+func (k PrivateKey) Format(f fmt.State, verb rune) {
+	io.WriteString(f, redacted)
+}
+
+// LogValue implements slog.LogValuer without revealing d, which would otherwise log MarshalText.
+func (k PrivateKey) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// Destroy overwrites d with zero. The key can not be used after Destroy.
+func (k *PrivateKey) Destroy() {
+	k.d = scalar{}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (k PrivateKey) MarshalBinary() ([]byte, error) {
+	if k.d.isZero() {
+		return nil, fmt.Errorf("empty private key")
+	}
+	return k.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (k *PrivateKey) UnmarshalBinary(bs []byte) error {
+	pk, err := ParsePrivateKey(bs)
+	if err != nil {
+		return err
+	}
+	*k = *pk
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k PrivateKey) MarshalText() ([]byte, error) {
+	return marshalText(k.MarshalBinary())
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *PrivateKey) UnmarshalText(text []byte) error {
+	return unmarshalText(text, k.UnmarshalBinary)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (k PrivateKey) MarshalJSON() ([]byte, error) {
+	return marshalJSON(k.MarshalText())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (k *PrivateKey) UnmarshalJSON(bs []byte) error {
+	return unmarshalJSON(bs, k.UnmarshalText)
+}
+
+// PublicKey is a public key P: a point on the curve.
+type PublicKey struct {
+	P *Point
+}
+
+// NewPublicKey returns the public key for P.
+func NewPublicKey(P *Point) (*PublicKey, error) {
+	if !oncurve(P) {
+		return nil, ErrPubKeyNotOnCurve
+	}
+	return &PublicKey{&Point{new(big.Int).Set(x(P)), new(big.Int).Set(y(P))}}, nil
+}
+
+// ParsePublicKey parses the 33 byte encoding bytes(P) of a public key.
+func ParsePublicKey(bs []byte) (*PublicKey, error) {
+	if len(bs) != 33 {
+		return nil, fmt.Errorf("public key must be 33 bytes, got %d", len(bs))
+	}
+	P, err := ParsePoint(bs)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{P}, nil
+}
+
+// Equal reports whether pk and pub are the same public key.
+func (pk *PublicKey) Equal(pub crypto.PublicKey) bool {
+	o, ok := pub.(*PublicKey)
+	if !ok || pk.P == nil || o.P == nil {
+		return false
+	}
+	return x(pk.P).Cmp(x(o.P)) == 0 && y(pk.P).Cmp(y(o.P)) == 0
+}
+
+// Bytes returns bytes(P).
+func (pk *PublicKey) Bytes() []byte {
+	return pk.P.Bytes()
+}
+
+// String returns bytes(P) in hex.
+func (pk *PublicKey) String() string {
+	return hex.EncodeToString(pk.Bytes())
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (pk PublicKey) MarshalBinary() ([]byte, error) {
+	if pk.P == nil || infinite(pk.P) {
+		return nil, fmt.Errorf("empty public key")
+	}
+	return pk.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (pk *PublicKey) UnmarshalBinary(bs []byte) error {
+	k, err := ParsePublicKey(bs)
+	if err != nil {
+		return err
+	}
+	*pk = *k
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (pk PublicKey) MarshalText() ([]byte, error) {
+	return marshalText(pk.MarshalBinary())
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (pk *PublicKey) UnmarshalText(text []byte) error {
+	return unmarshalText(text, pk.UnmarshalBinary)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (pk PublicKey) MarshalJSON() ([]byte, error) {
+	return marshalJSON(pk.MarshalText())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (pk *PublicKey) UnmarshalJSON(bs []byte) error {
+	return unmarshalJSON(bs, pk.UnmarshalText)
+}
+
+// Signature is a signature (r, s) with r < p and s < n.
+type Signature struct {
+	r, s *big.Int
+}
+
+// ParseSignature parses the 64 byte encoding bytes(r) || bytes(s) of a signature.
+func ParseSignature(bs []byte) (*Signature, error) {
+	if len(bs) != 64 {
+		return nil, ErrSigLength
+	}
+	r := intbs(bs[0:32])
+	if r.Cmp(p) >= 0 {
+		return nil, ErrSigROutOfRange
+	}
+	s := intbs(bs[32:64])
+	if s.Cmp(n) >= 0 {
+		return nil, ErrSigSOutOfRange
+	}
+	return &Signature{r, s}, nil
+}
+
+// R returns r.
+func (sig *Signature) R() *big.Int {
+	return new(big.Int).Set(sig.r)
+}
+
+// S returns s.
+func (sig *Signature) S() *big.Int {
+	return new(big.Int).Set(sig.s)
+}
+
+// Bytes returns bytes(r) || bytes(s).
+func (sig *Signature) Bytes() []byte {
+	return ll(bytes(sig.r), bytes(sig.s))
+}
+
+// String returns bytes(r) || bytes(s) in hex.
+func (sig *Signature) String() string {
+	return hex.EncodeToString(sig.Bytes())
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (sig Signature) MarshalBinary() ([]byte, error) {
+	if sig.r == nil || sig.s == nil {
+		return nil, fmt.Errorf("empty signature")
+	}
+	return sig.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (sig *Signature) UnmarshalBinary(bs []byte) error {
+	s, err := ParseSignature(bs)
+	if err != nil {
+		return err
+	}
+	*sig = *s
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (sig Signature) MarshalText() ([]byte, error) {
+	return marshalText(sig.MarshalBinary())
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (sig *Signature) UnmarshalText(text []byte) error {
+	return unmarshalText(text, sig.UnmarshalBinary)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (sig Signature) MarshalJSON() ([]byte, error) {
+	return marshalJSON(sig.MarshalText())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (sig *Signature) UnmarshalJSON(bs []byte) error {
+	return unmarshalJSON(bs, sig.UnmarshalText)
+}
+
+// marshalText hex encodes the result of a MarshalBinary.
+func marshalText(bs []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	text := make([]byte, hex.EncodedLen(len(bs)))
+	hex.Encode(text, bs)
+	return text, nil
+}
+
+// unmarshalText hex decodes text and passes it to an UnmarshalBinary.
+func unmarshalText(text []byte, unmarshal func([]byte) error) error {
+	bs := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(bs, text); err != nil {
+		return fmt.Errorf("invalid hex : %v", err)
+	}
+	return unmarshal(bs)
+}
+
+// marshalJSON quotes the result of a MarshalText as a JSON string.
+func marshalJSON(text []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// unmarshalJSON passes a JSON string to an UnmarshalText.
+func unmarshalJSON(bs []byte, unmarshal func([]byte) error) error {
+	var s string
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return err
+	}
+	return unmarshal([]byte(s))
+}
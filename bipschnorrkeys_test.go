@@ -0,0 +1,195 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	// Test vector 2
+	pri, _ := new(big.Int).SetString("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", 16)
+	pubbs, _ := hex.DecodeString("02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659")
+	msg, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	sign, _ := hex.DecodeString("2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D1E51A22CCEC35599B8F266912281F8365FFC2D035A230434A1A64DC59F7013FD")
+
+	k, err := bipschnorr.NewPrivateKey(pri)
+	if err != nil {
+		t.Fatalf("NewPrivateKey : %v", err)
+	}
+	if k.D().Cmp(pri) != 0 {
+		t.Errorf("no match D : %x", k.D())
+	}
+	k2, err := bipschnorr.ParsePrivateKey(k.Bytes())
+	if err != nil || k2.D().Cmp(pri) != 0 {
+		t.Errorf("ParsePrivateKey : %v", err)
+	}
+	pk := k.PublicKey()
+	if !reflect.DeepEqual(pk.Bytes(), pubbs) {
+		t.Errorf("no match PublicKey : %s", pk)
+	}
+	pk2, err := bipschnorr.ParsePublicKey(pubbs)
+	if err != nil || !reflect.DeepEqual(pk2.Bytes(), pubbs) {
+		t.Errorf("ParsePublicKey : %v", err)
+	}
+	sig, err := bipschnorr.ParseSignature(sign)
+	if err != nil {
+		t.Fatalf("ParseSignature : %v", err)
+	}
+	if !strings.EqualFold(sig.String(), hex.EncodeToString(sign)) {
+		t.Errorf("no match Signature : %s", sig)
+	}
+	if !bipschnorr.Verification(pk.P, msg, sig.Bytes()) {
+		t.Errorf("fail Schnorr_verify")
+	}
+
+	type message struct {
+		Pri *bipschnorr.PrivateKey
+		Pub bipschnorr.PublicKey
+		Sig bipschnorr.Signature
+	}
+	bs, err := json.Marshal(message{k, *pk, *sig})
+	if err != nil {
+		t.Fatalf("json.Marshal : %v", err)
+	}
+	var m message
+	if err := json.Unmarshal(bs, &m); err != nil {
+		t.Fatalf("json.Unmarshal : %v %s", err, bs)
+	}
+	if m.Pri.D().Cmp(pri) != 0 || !reflect.DeepEqual(m.Pub.Bytes(), pubbs) || !reflect.DeepEqual(m.Sig.Bytes(), sign) {
+		t.Errorf("no match json : %s", bs)
+	}
+	text, _ := sig.MarshalText()
+	var sig2 bipschnorr.Signature
+	if err := sig2.UnmarshalText(text); err != nil || !reflect.DeepEqual(sig2.Bytes(), sign) {
+		t.Errorf("UnmarshalText : %v", err)
+	}
+}
+
+func TestKeysInvalid(t *testing.T) {
+	for _, d := range []*big.Int{big.NewInt(0), new(big.Int).Neg(big.NewInt(1))} {
+		if _, err := bipschnorr.NewPrivateKey(d); err == nil {
+			t.Errorf("NewPrivateKey accepted %v", d)
+		}
+	}
+	for _, h := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+		"01",
+	} {
+		bs, _ := hex.DecodeString(h)
+		if _, err := bipschnorr.ParsePrivateKey(bs); err == nil {
+			t.Errorf("ParsePrivateKey accepted %s", h)
+		}
+	}
+	for _, h := range []string{
+		// Test vector 5: not on the curve
+		"03EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+		"04DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"02FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+		"02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA6",
+	} {
+		bs, _ := hex.DecodeString(h)
+		if _, err := bipschnorr.ParsePublicKey(bs); err == nil {
+			t.Errorf("ParsePublicKey accepted %s", h)
+		}
+	}
+	for _, h := range []string{
+		// r equal to the field size
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F1E51A22CCEC35599B8F266912281F8365FFC2D035A230434A1A64DC59F7013FD",
+		// s equal to the curve order
+		"2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1DFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+		"2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D",
+	} {
+		bs, _ := hex.DecodeString(h)
+		if _, err := bipschnorr.ParseSignature(bs); err == nil {
+			t.Errorf("ParseSignature accepted %s", h)
+		}
+	}
+	var pk bipschnorr.PublicKey
+	if err := json.Unmarshal([]byte(`"zz"`), &pk); err == nil {
+		t.Errorf("UnmarshalJSON accepted invalid hex")
+	}
+	if _, err := json.Marshal(pk); err == nil {
+		t.Errorf("MarshalJSON accepted an empty public key")
+	}
+}
+
+func TestSigner(t *testing.T) {
+	// Test vector 2
+	pri, _ := new(big.Int).SetString("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", 16)
+	msg, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	sign, _ := hex.DecodeString("2A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D1E51A22CCEC35599B8F266912281F8365FFC2D035A230434A1A64DC59F7013FD")
+
+	k, _ := bipschnorr.NewPrivateKey(pri)
+	var signer crypto.Signer = k
+	pk, ok := signer.Public().(*bipschnorr.PublicKey)
+	if !ok || !pk.Equal(k.PublicKey()) {
+		t.Fatalf("no match Public : %v", signer.Public())
+	}
+	other, _ := bipschnorr.NewPrivateKey(big.NewInt(1))
+	if pk.Equal(other.PublicKey()) {
+		t.Errorf("Equal for different keys")
+	}
+
+	sig, err := signer.Sign(rand.Reader, msg, &bipschnorr.SignerOpts{Deterministic: true})
+	if err != nil || !reflect.DeepEqual(sig, sign) {
+		t.Errorf("no match Sign : %x %v", sig, err)
+	}
+	sig, err = signer.Sign(rand.Reader, msg, crypto.Hash(0))
+	if err != nil || reflect.DeepEqual(sig, sign) {
+		t.Errorf("Sign without auxiliary randomness : %x %v", sig, err)
+	}
+	if !bipschnorr.Verification(pk.P, msg, sig) {
+		t.Errorf("fail Schnorr_verify")
+	}
+	if _, err := signer.Sign(rand.Reader, msg[:31], nil); err == nil {
+		t.Errorf("Sign accepted a short digest")
+	}
+	// A nil rand is only accepted for a deterministic nonce.
+	if _, err := signer.Sign(nil, msg, crypto.Hash(0)); err == nil {
+		t.Errorf("Sign accepted a nil rand")
+	}
+	sig, err = signer.Sign(nil, msg, &bipschnorr.SignerOpts{Deterministic: true})
+	if err != nil || !reflect.DeepEqual(sig, sign) {
+		t.Errorf("no match Sign with a nil rand : %x %v", sig, err)
+	}
+	k.Destroy()
+	if _, err := signer.Sign(nil, msg, &bipschnorr.SignerOpts{Deterministic: true}); err == nil {
+		t.Errorf("Sign after Destroy")
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	k, err := bipschnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey : %v", err)
+	}
+	if k.D().Sign() <= 0 {
+		t.Errorf("out of range : %s", k)
+	}
+
+	// Zero and n are rejected, the next 32 bytes are used.
+	n, _ := hex.DecodeString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141")
+	one, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	r := bytes.NewReader(append(append(make([]byte, 32), n...), one...))
+	k, err = bipschnorr.GenerateKey(r)
+	if err != nil {
+		t.Fatalf("GenerateKey : %v", err)
+	}
+	if k.D().Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("no match GenerateKey : %s", k)
+	}
+
+	if _, err := bipschnorr.GenerateKey(bytes.NewReader(n)); err == nil {
+		t.Errorf("GenerateKey ignored the reader error")
+	}
+}
@@ -7,15 +7,20 @@ import (
 
 // Muser is user for multisignatures.
 type Muser struct {
-	i  int        // index of user
-	u  int        // number of users
-	d  *big.Int   // secret key
-	m  []byte     // message
-	ps []*Point   // public keys of all users
-	mu []*big.Int // μ of all users
-	hs [][]byte   // hash values of all users
-	rs []*Point   // random points of all users
-	ss [][]byte   // signs of all users
+	i  int            // index of user
+	u  int            // number of users
+	d  *SecretScalar  // secret key
+	m  []byte         // message
+	ps []*Point       // public keys of all users
+	mu []*big.Int     // μ of all users
+	ka *KeyAggContext // key aggregation of all users
+	hs [][]byte       // hash values of all users
+	rs []*Point       // random points of all users
+	ss [][]byte       // signs of all users
+
+	tweaked bool      // whether to sign for the Taproot output key
+	root    []byte    // merkle root of the Taproot tweak
+	nf      NonceFunc // nonce function
 }
 
 // NewMultiUser returns Muser.
@@ -26,14 +31,15 @@ func NewMultiUser(i, u int, d *big.Int, m []byte) (*Muser, error) {
 	user := &Muser{}
 	user.i = i
 	user.u = u
-	user.d = d
+	user.d = NewSecretScalar(d)
 	user.m = m
 	user.ps = make([]*Point, u)
 	user.mu = make([]*big.Int, u)
 	user.hs = make([][]byte, u)
 	user.rs = make([]*Point, u)
 	user.ss = make([][]byte, u)
-	user.ps[i-1] = pointMul(d, G)
+	user.ps[i-1] = NewPoint(d)
+	user.nf = DraftNonce{}
 	return user, nil
 }
 
@@ -47,24 +53,38 @@ func (u *Muser) SetPublicKey(i int, pubkey *Point) error {
 	if i < 1 || u.u < i || pubkey == nil {
 		return fmt.Errorf("illegal parameter")
 	}
+	// Fail if P_i is not on the curve, before it is stored.
+	if !oncurve(pubkey) {
+		return fmt.Errorf("public key from the user(%d) : %v", i, ErrPubKeyNotOnCurve)
+	}
+	prev := u.ps[i-1]
 	u.ps[i-1] = pubkey
-	bs := []byte{}
 	for _, ps := range u.ps {
 		if ps == nil {
 			return nil
 		}
-		bs = append(bs, ps.Bytes()...)
 	}
-	c := hash(bs)
+	// μ_i is the KeyAgg coefficient of the i-th public key, so P is the aggregate key of other MuSig2 wallets.
+	ka, err := KeyAgg(u.ps)
+	if err != nil {
+		// Keep the previous key, so the user is left as it was.
+		u.ps[i-1] = prev
+		return fmt.Errorf("public key from the user(%d) : %v", i, err)
+	}
+	u.ka = ka
 	for i := range u.mu {
-		u.mu[i] = intbs(ll(c, big.NewInt(int64(i+1)).Bytes()))
+		u.mu[i] = ka.coefficient(i).bigInt()
 	}
 	return nil
 }
 
 // Hash returns the hash value of the random point.
 func (u *Muser) Hash() []byte {
-	return hash(u.RandomPoint().Bytes())
+	R := u.RandomPoint()
+	if R == nil {
+		return nil
+	}
+	return hash(R.Bytes())
 }
 
 // SetHash sets a public key of users.
@@ -76,13 +96,40 @@ func (u *Muser) SetHash(i int, h []byte) error {
 	return nil
 }
 
-// RandomPoint returns the random point.
+// RandomPoint returns the random point, or nil after Close.
 func (u *Muser) RandomPoint() *Point {
-	k := mod(intbs(hash(ll(bytes(u.d), u.m))), n)
-	R := pointMul(k, G)
+	if u.d == nil {
+		return nil
+	}
+	k := u.nonce()
+	R := scalarBaseMul(&k)
+	k = scalar{}
 	return R
 }
 
+// nonce returns the secret nonce k = int(nonce(bytes(d), m)) mod n, by default int(hash(bytes(d) || m)) mod n.
+func (u *Muser) nonce() scalar {
+	var k scalar
+	db := u.d.Bytes()
+	// Wipe bytes(d) on return.
+	defer func() {
+		for i := range db {
+			db[i] = 0
+		}
+	}()
+	k.setBytes(u.nf.Nonce(db, u.m))
+	return k
+}
+
+// SetNonceFunc sets the nonce function, which must not change once the random point has been sent.
+func (u *Muser) SetNonceFunc(nf NonceFunc) error {
+	if nf == nil {
+		return fmt.Errorf("illegal parameter")
+	}
+	u.nf = nf
+	return nil
+}
+
 // SetRandomPoint sets a random point of users.
 func (u *Muser) SetRandomPoint(i int, R *Point) error {
 	if i < 1 || u.u < i || R == nil {
@@ -119,16 +166,32 @@ func (u *Muser) Sign() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	k := mod(intbs(hash(ll(bytes(u.d), u.m))), n)
-	if jacobi(y(R)).Cmp(big.NewInt(1)) != 0 {
-		k = sub(n, k)
+	var mu, d scalar
+	k := u.nonce()
+	// Wipe the nonce and the copy of the secret key on return.
+	defer func() {
+		k, d = scalar{}, scalar{}
+	}()
+	// Fail if k = 0.
+	if k.isZero() {
+		return nil, ErrNonceZero
 	}
-	P, err := u.P()
+	Q, neg, _, err := u.key()
 	if err != nil {
 		return nil, err
 	}
-	e := mod(intbs(hash(ll(bytes(x(R)), P.Bytes(), u.m))), n)
-	s := bytes(mod(add(k, mul(e, mul(u.mu[u.i-1], u.d))), n))
+	e, negk := challenge(R, Q, u.m, u.tweaked)
+	if negk {
+		k.neg(&k)
+	}
+	mu.setInt(u.mu[u.i-1])
+	d = u.d.k
+	// Q = -P + tG signs with -d.
+	if neg {
+		d.neg(&d)
+	}
+	// s = k + eμd
+	s := e.mul(e, mu.mul(&mu, &d)).add(e, &k).bytes()
 	return s, nil
 }
 
@@ -147,13 +210,16 @@ func (u *Muser) CheckSign() error {
 	if err != nil {
 		return err
 	}
-	P, err := u.P()
+	Q, neg, _, err := u.key()
 	if err != nil {
 		return err
 	}
-	e := mod(intbs(hash(ll(bytes(x(R)), P.Bytes(), u.m))), n)
-	// -e
-	me := sub(n, e)
+	e, _ := challenge(R, Q, u.m, u.tweaked)
+	// -e, or e when Q = -P + tG
+	me := sub(n, e.bigInt())
+	if neg {
+		me = e.bigInt()
+	}
 	for j := range u.ss {
 		if u.i == j+1 {
 			continue
@@ -163,19 +229,29 @@ func (u *Muser) CheckSign() error {
 		if u.ss[j] == nil || Pj == nil || Rj == nil {
 			return fmt.Errorf("not received public key , random point or sign from the user(%d)", j+1)
 		}
-		sj := intbs(u.ss[j])
-		// Fail if sj ≥ n.
-		if sj.Cmp(n) >= 0 {
-			return fmt.Errorf("sign from user(%d) is over", j+1)
-		}
-		R := pointAdd(pointMul(sj, G), pointMul(mul(me, u.mu[j]), Pj))
-		if infinite(R) || x(R).Cmp(x(Rj)) != 0 {
-			return fmt.Errorf("fail to check sign")
+		if err := checkSign(j+1, u.ss[j], me, u.mu[j], Pj, Rj); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// checkSign checks the sign s of user(j) with x(sG - eμ_jP_j) = x(R_j), where me is -e, or e when Q = -P + tG.
+func checkSign(j int, s []byte, me, mu *big.Int, Pj, Rj *Point) error {
+	sj := intbs(s)
+	// Fail if sj ≥ n.
+	if sj.Cmp(n) >= 0 {
+		return fmt.Errorf("sign from the user(%d) is over", j)
+	}
+	var ks scalar
+	J := ecmult(ks.setInt(sj), []ecmultTerm{term(mul(me, mu), Pj)})
+	R := J.point()
+	if infinite(R) || x(R).Cmp(x(Rj)) != 0 {
+		return fmt.Errorf("fail to check sign from the user(%d)", j)
+	}
+	return nil
+}
+
 // Signing returns the multisignature.
 func (u *Muser) Signing() ([]byte, error) {
 	R, err := u.sumR()
@@ -197,11 +273,27 @@ func (u *Muser) Signing() ([]byte, error) {
 		sj := intbs(u.ss[j])
 		s = mod(add(s, sj), n)
 	}
-	return ll(bytes(x(R)), bytes(s)), nil
+	// s = Σs_j + et
+	Q, _, t, err := u.key()
+	if err != nil {
+		return nil, err
+	}
+	e, _ := challenge(R, Q, u.m, u.tweaked)
+	s = mod(add(s, mul(e.bigInt(), t.bigInt())), n)
+	sig := ll(bytes(x(R)), bytes(s))
+	// Fail if Verification(Q, m, sig) fails.
+	if !verification(Q, u.m, sig, u.tweaked) {
+		return nil, ErrSigningFault
+	}
+	return sig, nil
 }
 
 func (u *Muser) sumR() (*Point, error) {
-	R := u.RandomPoint()
+	if u.d == nil {
+		return nil, ErrClosed
+	}
+	var R jacobianPoint
+	R.addPoint(u.RandomPoint())
 	for j, r := range u.rs {
 		if u.i == j+1 {
 			continue
@@ -209,19 +301,57 @@ func (u *Muser) sumR() (*Point, error) {
 		if r == nil {
 			return nil, fmt.Errorf("not received random point from the user(%d)", j+1)
 		}
-		R = pointAdd(R, r)
+		R.addPoint(r)
 	}
-	return R, nil
+	return R.point(), nil
 }
 
-// P returns public key for multisignature.
+// P returns public key for multisignature, the aggregate key KeyAgg(P_1..u).
 func (u *Muser) P() (*Point, error) {
-	P := &Point{}
 	for j, p := range u.ps {
 		if p == nil {
 			return nil, fmt.Errorf("not received public key from the user(%d)", j+1)
 		}
-		P = pointAdd(P, pointMul(u.mu[j], p))
 	}
-	return P, nil
+	if u.ka == nil {
+		return nil, fmt.Errorf("no aggregate key of the public keys")
+	}
+	return u.ka.Q(), nil
+}
+
+// SetTweak makes the users sign for the Taproot output key TweakPublicKey(P, merkleRoot) instead of P.
+func (u *Muser) SetTweak(merkleRoot []byte) error {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return fmt.Errorf("illegal parameter")
+	}
+	u.tweaked = true
+	u.root = merkleRoot
+	return nil
+}
+
+// Q returns the key the multisignature is valid for, TweakPublicKey(P, merkleRoot) after SetTweak and P otherwise.
+func (u *Muser) Q() (*Point, error) {
+	Q, _, _, err := u.key()
+	return Q, err
+}
+
+// key returns Q = ±P + tG, whether P is negated, and t, which is zero without a tweak.
+func (u *Muser) key() (*Point, bool, *scalar, error) {
+	P, err := u.P()
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if !u.tweaked {
+		return P, false, &scalar{}, nil
+	}
+	return tapTweakEven(P, u.root)
+}
+
+// Close wipes the secret key, from which the nonce is derived, once the multisignature is produced.
+// The user can not sign after Close.
+func (u *Muser) Close() {
+	if u.d != nil {
+		u.d.Destroy()
+		u.d = nil
+	}
 }
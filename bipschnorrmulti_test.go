@@ -3,16 +3,56 @@ package bipschnorr_test
 import (
 	"crypto/rand"
 	"math/big"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/tnakagawa/bipschnorr"
 
-	"github.com/btcsuite/btcd/btcec"
-
 	"testing"
 )
 
 func TestMultisignature(t *testing.T) {
+	multisignature(t, false, nil)
+}
+
+func TestMultisignatureTweak(t *testing.T) {
+	multisignature(t, true, nil)
+	multisignature(t, true, rndbs())
+}
+
+func TestMultiUserPublicKey(t *testing.T) {
+	m := rndbs()
+	u, _ := bipschnorr.NewMultiUser(1, 2, rndbi(), m)
+	// An invalid key is rejected with the index of its user and is not stored.
+	err := u.SetPublicKey(2, &bipschnorr.Point{})
+	if err == nil || !strings.Contains(err.Error(), "user(2)") {
+		t.Errorf("SetPublicKey : %v", err)
+	}
+	if _, err := u.P(); err == nil {
+		t.Errorf("P without the public key of user(2)")
+	}
+	if _, err := u.Q(); err == nil {
+		t.Errorf("Q without the public key of user(2)")
+	}
+	if _, err := u.Sign(); err == nil {
+		t.Errorf("Sign without the public key of user(2)")
+	}
+	P2 := bipschnorr.NewPoint(rndbi())
+	if err := u.SetPublicKey(2, P2); err != nil {
+		t.Fatalf("SetPublicKey : %v", err)
+	}
+	P, err := u.P()
+	if err != nil {
+		t.Fatalf("P : %v", err)
+	}
+	ctx, _ := bipschnorr.KeyAgg([]*bipschnorr.Point{u.PublicKey(), P2})
+	if !ctx.Q().Equal(P) {
+		t.Errorf("no match P : %x", P.Bytes())
+	}
+}
+
+func multisignature(t *testing.T, tweak bool, root []byte) {
 	start := time.Now()
 	t.Logf("Introduction / %fs", (time.Now().Sub(start)).Seconds())
 	u := rndi(10) + 2
@@ -26,6 +66,9 @@ func TestMultisignature(t *testing.T) {
 			t.Fail()
 			return
 		}
+		if tweak {
+			user.SetTweak(root)
+		}
 		users = append(users, user)
 	}
 	t.Logf("Step1 / %fs", (time.Now().Sub(start)).Seconds())
@@ -37,12 +80,19 @@ func TestMultisignature(t *testing.T) {
 			users[j].SetPublicKey(i+1, users[i].PublicKey())
 		}
 	}
-	P, err := users[0].P()
+	P, err := users[0].Q()
 	if err != nil {
 		t.Logf("error : %+v", err)
 		t.Fail()
 		return
 	}
+	if tweak {
+		iP, _ := users[0].P()
+		Q, _ := bipschnorr.TweakPublicKey(iP, root)
+		if !reflect.DeepEqual(P, Q) {
+			t.Errorf("no match Q : %x", P.Bytes())
+		}
+	}
 	t.Logf("u : %d", u)
 	t.Logf("P : %x", P.Bytes())
 	t.Logf("m : %x", m)
@@ -104,13 +154,34 @@ func TestMultisignature(t *testing.T) {
 		t.Fail()
 		return
 	}
+	// A tweaked multisignature is a BIP340 signature for the Taproot output key.
 	v := bipschnorr.Verification(P, m, sig)
+	if tweak {
+		v = bipschnorr.VerificationBIP340(P.BytesXOnly(), m, sig)
+	}
 	t.Logf("Verification:%v / %fs", v, (time.Now().Sub(start)).Seconds())
 	if !v {
 		t.Logf("fail verify : %v", v)
 		t.Fail()
 		return
 	}
+	// A watch-only service verifies with the public keys alone.
+	pubkeys := []*bipschnorr.Point{}
+	for _, user := range users {
+		pubkeys = append(pubkeys, user.PublicKey())
+	}
+	ctx, err := bipschnorr.KeyAgg(pubkeys)
+	if err != nil {
+		t.Fatalf("KeyAgg : %v", err)
+	}
+	v = ctx.Verification(m, sig)
+	if tweak {
+		ctx.TaprootTweak(root)
+		v = ctx.VerificationBIP340(m, sig)
+	}
+	if !ctx.Q().Equal(P) || !v {
+		t.Errorf("fail watch-only verify : %x", ctx.Q().Bytes())
+	}
 }
 
 func rndbs() []byte {
@@ -120,8 +191,11 @@ func rndbs() []byte {
 }
 
 func rndbi() *big.Int {
-	r := new(big.Int).Mod(new(big.Int).SetBytes(rndbs()), btcec.S256().N)
-	return r
+	k, err := bipschnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return k.D()
 }
 
 func rndi(n int) int {
@@ -0,0 +1,328 @@
+package bipschnorr
+
+// MuSig2 signing sessions, which produce BIP340 signatures for the aggregate key of KeyAgg.
+// https://github.com/bitcoin/bips/blob/master/bip-0327.mediawiki
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+)
+
+// Errors returned by signing sessions.
+var (
+	ErrSecNonceUsed      = errors.New("secret nonce already used")
+	ErrPartialSigInvalid = errors.New("invalid partial signature")
+)
+
+// SecNonce is the secret nonce of a signer, the counterpart of the 66 byte public nonce from NonceGen.
+// Sign wipes it, so it signs once at most. It prints redacted and has no encoding, so it is never stored and reused.
+type SecNonce struct {
+	k1, k2 scalar
+	pk     []byte
+}
+
+// String implements fmt.Stringer without revealing the nonce.
+func (sn SecNonce) String() string {
+	return redacted
+}
+
+// GoString implements fmt.GoStringer without revealing the nonce.
+func (sn SecNonce) GoString() string {
+	return "bipschnorr.SecNonce{" + redacted + "}"
+}
+
+// Format implements fmt.Formatter without revealing the nonce with any verb.
+func (sn SecNonce) Format(f fmt.State, verb rune) {
+	io.WriteString(f, redacted)
+}
+
+// LogValue implements slog.LogValuer without revealing the nonce.
+func (sn SecNonce) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// NonceGen is
+// Input:
+// Randomness rand: a source of 32 fresh bytes for every call
+// The secret key d: an integer in the range 1..n-1, or nil
+// The public key P of the signer
+// The aggregate public key aggpk: a 32 byte array, or nil
+// The message m: a byte array, or nil
+// Auxiliary input extra: a byte array, or nil
+// It returns the secret nonce and the public nonce to send to the other signers.
+func NonceGen(rand io.Reader, d *big.Int, P *Point, aggpk, m, extra []byte) (*SecNonce, []byte, error) {
+	if !oncurve(P) {
+		return nil, nil, ErrPubKeyNotOnCurve
+	}
+	if aggpk != nil && len(aggpk) != 32 {
+		return nil, nil, fmt.Errorf("aggregate public key must be 32 bytes, got %d", len(aggpk))
+	}
+	pk := P.Bytes()
+	// Let rand' be a 32-byte array freshly drawn uniformly at random.
+	rnd := make([]byte, 32)
+	if _, err := io.ReadFull(rand, rnd); err != nil {
+		return nil, nil, fmt.Errorf("reading random data : %v", err)
+	}
+	// If the optional argument sk is present, let rand be the byte-wise xor of sk and hash_MuSig/aux(rand').
+	if d != nil {
+		if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+			return nil, nil, fmt.Errorf("secret key out of range 1..n-1")
+		}
+		sk := bytes(d)
+		for i, b := range hashTag("MuSig/aux", rnd) {
+			rnd[i] = sk[i] ^ b
+		}
+	}
+	// If the optional argument m is not present, let m_prefixed = bytes(1, 0),
+	// otherwise let m_prefixed = bytes(1, 1) || bytes(8, len(m)) || m.
+	mp := []byte{0}
+	if m != nil {
+		mp = make([]byte, 9)
+		mp[0] = 1
+		binary.BigEndian.PutUint64(mp[1:], uint64(len(m)))
+		mp = append(mp, m...)
+	}
+	el := make([]byte, 4)
+	binary.BigEndian.PutUint32(el, uint32(len(extra)))
+	sn := &SecNonce{pk: pk}
+	for i, k := range []*scalar{&sn.k1, &sn.k2} {
+		// Let k_i = int(hash_MuSig/nonce(rand || bytes(1, len(pk)) || pk || bytes(1, len(aggpk)) || aggpk ||
+		// m_prefixed || bytes(4, len(extra_in)) || extra_in || bytes(1, i - 1))) mod n for i = 1,2.
+		k.setBytes(hashTag("MuSig/nonce", ll(rnd, []byte{byte(len(pk))}, pk, []byte{byte(len(aggpk))}, aggpk, mp, el, extra, []byte{byte(i)})))
+		// Fail if k_1 = 0 or k_2 = 0.
+		if k.isZero() {
+			return nil, nil, ErrNonceZero
+		}
+	}
+	// Let pubnonce = cbytes(k_1⋅G) || cbytes(k_2⋅G).
+	return sn, sn.pubnonce(), nil
+}
+
+// pubnonce returns the public nonce of sn.
+func (sn *SecNonce) pubnonce() []byte {
+	return ll(scalarBaseMul(&sn.k1).Bytes(), scalarBaseMul(&sn.k2).Bytes())
+}
+
+// NonceAgg is
+// Input:
+// The public nonces of all signers: 66 byte arrays
+// It returns the 66 byte aggregate nonce, which every signer starts the Session with.
+func NonceAgg(pubnonces [][]byte) ([]byte, error) {
+	aggnonce := []byte{}
+	for j := 0; j < 2; j++ {
+		// Let R_j = Σ cpoint(pubnonce_i[(j-1)*33:j*33]); fail if that fails and blame signer i.
+		var R jacobianPoint
+		for i, pubnonce := range pubnonces {
+			if len(pubnonce) != 66 {
+				return nil, fmt.Errorf("public nonce from signer %d must be 66 bytes, got %d", i+1, len(pubnonce))
+			}
+			Rij, err := cpoint(pubnonce[j*33 : (j+1)*33])
+			if err != nil {
+				return nil, fmt.Errorf("public nonce from signer %d : %v", i+1, err)
+			}
+			R.addPoint(Rij)
+		}
+		// Let aggnonce = cbytes_ext(R_1) || cbytes_ext(R_2).
+		aggnonce = append(aggnonce, cbytesExt(R.point())...)
+	}
+	return aggnonce, nil
+}
+
+// Session is a MuSig2 signing session of the signers of a KeyAggContext for an aggregate nonce and a message.
+type Session struct {
+	ctx *KeyAggContext
+	m   []byte
+	b   scalar // nonce coefficient
+	e   scalar // challenge
+	R   *Point // final nonce
+}
+
+// NewSession returns the session for the aggregate nonce from NonceAgg and the message m.
+// ctx must have all its tweaks applied and not change during the session.
+func NewSession(ctx *KeyAggContext, aggnonce, m []byte) (*Session, error) {
+	if len(aggnonce) != 66 {
+		return nil, fmt.Errorf("aggregate nonce must be 66 bytes, got %d", len(aggnonce))
+	}
+	R1, err := cpointExt(aggnonce[0:33])
+	if err != nil {
+		return nil, fmt.Errorf("aggregate nonce : %v", err)
+	}
+	R2, err := cpointExt(aggnonce[33:66])
+	if err != nil {
+		return nil, fmt.Errorf("aggregate nonce : %v", err)
+	}
+	s := &Session{ctx: ctx, m: m}
+	// Let b = int(hash_MuSig/noncecoef(aggnonce || xbytes(Q) || m)) mod n.
+	s.b.setBytes(hashTag("MuSig/noncecoef", ll(aggnonce, ctx.q.BytesXOnly(), m)))
+	// Let R' = R'_1 + b⋅R'_2; let R = R' unless it is infinite, and R = G otherwise.
+	R := new(Point).Add(R1, new(Point).ScalarMult(&Scalar{k: s.b}, R2))
+	if infinite(R) {
+		R = NewPoint(big.NewInt(1))
+	}
+	s.R = R
+	// Let e = int(hash_BIP0340/challenge(xbytes(R) || xbytes(Q) || m)) mod n.
+	s.e.setBytes(hashTag("BIP0340/challenge", ll(R.BytesXOnly(), ctx.q.BytesXOnly(), m)))
+	return s, nil
+}
+
+// Sign is
+// Input:
+// The secret nonce sn from NonceGen, which is wiped
+// The secret key d: an integer in the range 1..n-1
+// It returns the 32 byte partial signature, verified before it is returned.
+func (s *Session) Sign(sn *SecNonce, d *big.Int) ([]byte, error) {
+	// Let k'_1 = int(secnonce[0:32]) and k'_2 = int(secnonce[32:64]), and wipe secnonce.
+	k1, k2 := sn.k1, sn.k2
+	sn.k1, sn.k2 = scalar{}, scalar{}
+	defer func() {
+		k1, k2 = scalar{}, scalar{}
+	}()
+	// Fail if k'_i = 0, which a wiped secnonce is.
+	if k1.isZero() || k2.isZero() {
+		return nil, ErrSecNonceUsed
+	}
+	pubnonce := ll(scalarBaseMul(&k1).Bytes(), scalarBaseMul(&k2).Bytes())
+	// Let k_i = k'_i if has_even_y(R), otherwise let k_i = n - k'_i.
+	var nk scalar
+	odd := !hasEvenY(s.R)
+	k1.cmov(nk.neg(&k1), odd)
+	k2.cmov(nk.neg(&k2), odd)
+	// Let d' = int(sk); fail if d' = 0 or d' ≥ n.
+	if d == nil || d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("secret key out of range 1..n-1")
+	}
+	var sd, sk scalar
+	defer func() {
+		sd = scalar{}
+	}()
+	sd.setInt(d)
+	// Let P = d'⋅G; fail if secnonce[64:97] ≠ cbytes(P).
+	P := scalarBaseMul(&sd)
+	if !bseq(P.Bytes(), sn.pk) {
+		return nil, fmt.Errorf("secret nonce not generated for the public key")
+	}
+	// Let a = the KeyAgg coefficient of P; fail if that fails.
+	a, err := s.ctx.coefficientOf(sn.pk)
+	if err != nil {
+		return nil, err
+	}
+	// Let d = g⋅gacc⋅d' mod n, where g = 1 if has_even_y(Q) and g = n - 1 otherwise.
+	sd.mul(&sd, &s.ctx.gacc)
+	sd.cmov(nk.neg(&sd), !hasEvenY(s.ctx.q))
+	// Let s = (k_1 + b⋅k_2 + e⋅a⋅d) mod n.
+	sk.mul(&s.e, a).mul(&sk, &sd)
+	psig := sk.add(&sk, nk.mul(&s.b, &k2)).add(&sk, &k1).bytes()
+	// Fail if PartialSigVerifyInternal(psig, pubnonce, pk) fails.
+	if err := s.PartialSigVerify(psig, pubnonce, P); err != nil {
+		return nil, ErrSigningFault
+	}
+	return psig, nil
+}
+
+// PartialSigVerify is
+// Input:
+// The partial signature psig: a 32 byte array
+// The public nonce of the signer: a 66 byte array
+// The public key P of the signer
+// It returns nil if psig is the signer's partial signature in the session.
+func (s *Session) PartialSigVerify(psig, pubnonce []byte, P *Point) error {
+	if len(psig) != 32 {
+		return ErrSigLength
+	}
+	if len(pubnonce) != 66 {
+		return fmt.Errorf("public nonce must be 66 bytes, got %d", len(pubnonce))
+	}
+	if !oncurve(P) {
+		return ErrPubKeyNotOnCurve
+	}
+	// Let s = int(psig); fail if s ≥ n.
+	var ps scalar
+	if !ps.setBytes(psig) {
+		return ErrSigSOutOfRange
+	}
+	// Let R*' = cpoint(pubnonce[0:33]) + b⋅cpoint(pubnonce[33:66]).
+	R1, err := cpoint(pubnonce[0:33])
+	if err != nil {
+		return fmt.Errorf("public nonce : %v", err)
+	}
+	R2, err := cpoint(pubnonce[33:66])
+	if err != nil {
+		return fmt.Errorf("public nonce : %v", err)
+	}
+	a, err := s.ctx.coefficientOf(P.Bytes())
+	if err != nil {
+		return err
+	}
+	// Let R* = R*' if has_even_y(R), otherwise let R* = -R*'.
+	var one scalar
+	one.setUint(1)
+	b := s.b
+	if !hasEvenY(s.R) {
+		one.neg(&one)
+		b.neg(&b)
+	}
+	// Let g' = g⋅gacc mod n, where g = 1 if has_even_y(Q) and g = n - 1 otherwise.
+	var ea scalar
+	ea.mul(&s.e, a).mul(&ea, &s.ctx.gacc)
+	if !hasEvenY(s.ctx.q) {
+		ea.neg(&ea)
+	}
+	// Fail if s⋅G ≠ R* + e⋅a⋅g'⋅P.
+	var ms scalar
+	J := ecmult(ms.neg(&ps), []ecmultTerm{{k: one, P: R1.jacobian()}, {k: b, P: R2.jacobian()}, {k: ea, P: P.jacobian()}})
+	if !J.isInfinity() {
+		return ErrPartialSigInvalid
+	}
+	return nil
+}
+
+// PartialSigAgg is
+// Input:
+// The partial signatures of all signers: 32 byte arrays
+// It returns the 64 byte BIP340 signature of m for the public key ctx.PublicKey().
+func (s *Session) PartialSigAgg(psigs [][]byte) ([]byte, error) {
+	// Let s = Σ int(psig_i) mod n; fail if psig_i ≥ n and blame signer i.
+	var sum, si scalar
+	for i, psig := range psigs {
+		if len(psig) != 32 || !si.setBytes(psig) {
+			return nil, fmt.Errorf("partial signature from signer %d : %v", i+1, ErrSigSOutOfRange)
+		}
+		sum.add(&sum, &si)
+	}
+	// Let s = s + e⋅g⋅tacc mod n, where g = 1 if has_even_y(Q) and g = n - 1 otherwise.
+	var et scalar
+	et.mul(&s.e, &s.ctx.tacc)
+	if !hasEvenY(s.ctx.q) {
+		et.neg(&et)
+	}
+	// Return sig = xbytes(R) || bytes(32, s).
+	return ll(s.R.BytesXOnly(), sum.add(&sum, &et).bytes()), nil
+}
+
+// cpoint returns the point of the 33 byte compressed encoding bs.
+func cpoint(bs []byte) (*Point, error) {
+	if len(bs) != 33 {
+		return nil, fmt.Errorf("point must be 33 bytes, got %d", len(bs))
+	}
+	return ParsePoint(bs)
+}
+
+// cpointExt is cpoint which parses 33 zero bytes as the point at infinity.
+func cpointExt(bs []byte) (*Point, error) {
+	if bseq(bs, make([]byte, 33)) {
+		return &Point{}, nil
+	}
+	return cpoint(bs)
+}
+
+// cbytesExt returns the 33 byte compressed encoding of P, which is 33 zero bytes for the point at infinity.
+func cbytesExt(P *Point) []byte {
+	if infinite(P) {
+		return make([]byte, 33)
+	}
+	return P.Bytes()
+}
@@ -0,0 +1,285 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestNonceGen(t *testing.T) {
+	// BIP327 nonce_gen_vectors.json, no optional arguments
+	pk, _ := hex.DecodeString("02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9")
+	P, _ := bipschnorr.ParsePoint(pk)
+	_, pubnonce, err := bipschnorr.NonceGen(bytes.NewReader(make([]byte, 32)), nil, P, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NonceGen : %v", err)
+	}
+	// pubnonce = cbytes(k_1G) || cbytes(k_2G) of secnonce 890E83616A3BC464... || 9FD7E874E2334281...
+	k1, _ := new(bipschnorr.Scalar).SetBytes(mustHex("890E83616A3BC4640AB9B6374F21C81FF89CDDDBAFAA7475AE2A102A92E3EDB2"))
+	k2, _ := new(bipschnorr.Scalar).SetBytes(mustHex("9FD7E874E23342813A60D9646948242646B7951CA046B4B36D7D6078506D3C94"))
+	expected := append(new(bipschnorr.Point).ScalarBaseMult(k1).Bytes(), new(bipschnorr.Point).ScalarBaseMult(k2).Bytes()...)
+	if !bytes.Equal(pubnonce, expected) {
+		t.Errorf("no match pubnonce : %x", pubnonce)
+	}
+}
+
+func TestNonceAgg(t *testing.T) {
+	// BIP327 nonce_agg_vectors.json
+	pnonces := []string{
+		"020151C80F435648DF67A22B749CD798CE54E0321D034B92B709B567D60A42E66603BA47FBC1834437B3212E89A84D8425E7BF12E0245D98262268EBDCB385D50641",
+		"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B833",
+		"020151C80F435648DF67A22B749CD798CE54E0321D034B92B709B567D60A42E6660279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60379BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"04FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B833",
+	}
+	for _, v := range []struct {
+		idx      []int
+		expected string
+	}{
+		{[]int{0, 1}, "035FE1873B4F2967F52FEA4A06AD5A8ECCBE9D0FD73068012C894E2E87CCB5804B024725377345BDE0E9C33AF3C43C0A29A9249F2F2956FA8CFEB55C8573D0262DC8"},
+		// Sum of second points encoded as 33 zero bytes
+		{[]int{2, 3}, "035FE1873B4F2967F52FEA4A06AD5A8ECCBE9D0FD73068012C894E2E87CCB5804B000000000000000000000000000000000000000000000000000000000000000000"},
+		// Public nonce from signer 2 is invalid
+		{[]int{0, 4}, ""},
+	} {
+		ps := [][]byte{}
+		for _, i := range v.idx {
+			ps = append(ps, mustHex(pnonces[i]))
+		}
+		aggnonce, err := bipschnorr.NonceAgg(ps)
+		if v.expected == "" {
+			if err == nil || !strings.Contains(err.Error(), "signer 2") {
+				t.Errorf("NonceAgg %v : %v", v.idx, err)
+			}
+			continue
+		}
+		if err != nil || !strings.EqualFold(hex.EncodeToString(aggnonce), v.expected) {
+			t.Errorf("no match NonceAgg %v : %x %v", v.idx, aggnonce, err)
+		}
+	}
+}
+
+func TestSessionSign(t *testing.T) {
+	// BIP327 sign_verify_vectors.json, the signer's secret key is that of pubkeys[0]
+	d := new(big.Int).SetBytes(mustHex("7FB9E0E687ADA1EEBF7ECFE2F21E73EBDB51A7D450948DFE8D76D7F2D1007671"))
+	secnonce := mustHex("508B81A611F100A6B2B6B29656590898AF488BCF2E1F55CF22E5CFB84421FE61FA27FD49B1D50085B481285E1CA205D55C82CC1B31FF5CD54A489829355901F703935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9")
+	pubkeys := []string{
+		"03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9",
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA661",
+	}
+	pnonces := []string{
+		"0337C87821AFD50A8644D820A8F3E02E499C931865C2360FB43D0A0D20DAFE07EA0287BF891D2A6DEAEBADC909352AA9405D1428C15F4B75F04DAE642A95C2548480",
+		"0279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F817980279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"032DE2662628C90B03F5E720284EB52FF7D71F4284F627B68A853D78C78E1FFE9303E4C5524E83FFE1493B9077CF1CA6BEB2090C93D930321071AD40B2F44E599046",
+	}
+	aggnonce := "028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61037496A3CC86926D452CAFCFD55D25972CA1675D549310DE296BFF42F72EEEA8C9"
+	m := mustHex("F95466D086770E689964664219266FE5ED215C92AE20BAB5C9D79ADDDDF3C0CF")
+	for _, v := range []struct {
+		idx      []int
+		expected string
+	}{
+		{[]int{0, 1, 2}, "012ABBCB52B3016AC03AD82395A1A415C48B93DEF78718E62A7A90052FE224FB"},
+		{[]int{1, 0, 2}, "9FF2F7AAA856150CC8819254218D3ADEEB0535269051897724F9DB3789513A52"},
+		{[]int{1, 2, 0}, "FA23C359F6FAC4E7796BB93BC9F0532A95468C539BA20FF86D7C76ED92227900"},
+	} {
+		Ps := []*bipschnorr.Point{}
+		ps := [][]byte{}
+		for _, i := range v.idx {
+			P, _ := bipschnorr.ParsePoint(mustHex(pubkeys[i]))
+			Ps = append(Ps, P)
+			ps = append(ps, mustHex(pnonces[i]))
+		}
+		agg, err := bipschnorr.NonceAgg(ps)
+		if err != nil || !strings.EqualFold(hex.EncodeToString(agg), aggnonce) {
+			t.Errorf("no match NonceAgg %v : %x %v", v.idx, agg, err)
+		}
+		ctx, _ := bipschnorr.KeyAgg(Ps)
+		session, err := bipschnorr.NewSession(ctx, mustHex(aggnonce), m)
+		if err != nil {
+			t.Fatalf("NewSession : %v", err)
+		}
+		psig, err := session.Sign(bipschnorr.NewSecNonce(secnonce), d)
+		if err != nil || !strings.EqualFold(hex.EncodeToString(psig), v.expected) {
+			t.Errorf("no match Sign %v : %x %v", v.idx, psig, err)
+		}
+		P, _ := bipschnorr.ParsePoint(mustHex(pubkeys[0]))
+		if err := session.PartialSigVerify(mustHex(v.expected), mustHex(pnonces[0]), P); err != nil {
+			t.Errorf("PartialSigVerify %v : %v", v.idx, err)
+		}
+	}
+}
+
+func TestSessionTweak(t *testing.T) {
+	// BIP327 tweak_vectors.json, the signer's secret key is that of pubkeys[0]
+	d := new(big.Int).SetBytes(mustHex("7FB9E0E687ADA1EEBF7ECFE2F21E73EBDB51A7D450948DFE8D76D7F2D1007671"))
+	secnonce := mustHex("508B81A611F100A6B2B6B29656590898AF488BCF2E1F55CF22E5CFB84421FE61FA27FD49B1D50085B481285E1CA205D55C82CC1B31FF5CD54A489829355901F703935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9")
+	pubkeys := []string{
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9",
+	}
+	aggnonce := "028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61037496A3CC86926D452CAFCFD55D25972CA1675D549310DE296BFF42F72EEEA8C9"
+	tweaks := []string{
+		"E8F791FF9225A2AF0102AFFF4A9A723D9612A682A25EBE79802B263CDFCD83BB",
+		"AE2EA797CC0FE72AC5B97B97F3C6957D7E4199A167A58EB08BCAFFDA70AC0455",
+		"F52ECBC565B3D8BEA2DFD5B75A4F457E54369809322E4120831626F290FA87E0",
+		"1969AD73CC177FA0B4FCED6DF1F7BF9907E665FDE9BA196A74FED0A3CF5AEF9D",
+		// Tweak is out of range
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+	}
+	m := mustHex("F95466D086770E689964664219266FE5ED215C92AE20BAB5C9D79ADDDDF3C0CF")
+	for _, v := range []struct {
+		idx      []int
+		xonly    []bool
+		expected string
+	}{
+		{[]int{0}, []bool{true}, "E28A5C66E61E178C2BA19DB77B6CF9F7E2F0F56C17918CD13135E60CC848FE91"},
+		{[]int{0}, []bool{false}, "38B0767798252F21BF5702C48028B095428320F73A4B14DB1E25DE58543D2D2D"},
+		{[]int{0, 1}, []bool{false, true}, "408A0A21C4A0F5DACAF9646AD6EB6FECD7F7A11F03ED1F48DFFF2185BC2C2408"},
+		{[]int{0, 1, 2, 3}, []bool{false, false, true, true}, "45ABD206E61E3DF2EC9E264A6FEC8292141A633C28586388235541F9ADE75435"},
+		{[]int{0, 1, 2, 3}, []bool{true, false, true, false}, "B255FDCAC27B40C7CE7848E2D3B7BF5EA0ED756DA81565AC804CCCA3E1D5D239"},
+		{[]int{4}, []bool{false}, ""},
+	} {
+		Ps := []*bipschnorr.Point{}
+		for _, pk := range pubkeys {
+			P, _ := bipschnorr.ParsePoint(mustHex(pk))
+			Ps = append(Ps, P)
+		}
+		ctx, _ := bipschnorr.KeyAgg(Ps)
+		var err error
+		for i, j := range v.idx {
+			if err = ctx.ApplyTweak(mustHex(tweaks[j]), v.xonly[i]); err != nil {
+				break
+			}
+		}
+		if v.expected == "" {
+			if err == nil {
+				t.Errorf("ApplyTweak %v accepted", v.idx)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ApplyTweak %v : %v", v.idx, err)
+		}
+		session, err := bipschnorr.NewSession(ctx, mustHex(aggnonce), m)
+		if err != nil {
+			t.Fatalf("NewSession : %v", err)
+		}
+		psig, err := session.Sign(bipschnorr.NewSecNonce(secnonce), d)
+		if err != nil || !strings.EqualFold(hex.EncodeToString(psig), v.expected) {
+			t.Errorf("no match Sign %v %v : %x %v", v.idx, v.xonly, psig, err)
+		}
+	}
+}
+
+func TestMuSig2(t *testing.T) {
+	musig2(t, nil)
+}
+
+func TestMuSig2Tweak(t *testing.T) {
+	// Plain and x-only tweaks
+	musig2(t, func(ctx *bipschnorr.KeyAggContext) error {
+		if err := ctx.ApplyTweak(rndbs(), false); err != nil {
+			return err
+		}
+		return ctx.ApplyTweak(rndbs(), true)
+	})
+	// Taproot output keys with and without scripts
+	for _, root := range [][]byte{{}, rndbs()} {
+		musig2(t, func(ctx *bipschnorr.KeyAggContext) error {
+			Q, _ := bipschnorr.TweakPublicKey(ctx.Q(), root)
+			if err := ctx.TaprootTweak(root); err != nil {
+				return err
+			}
+			if !bytes.Equal(ctx.PublicKey(), Q.BytesXOnly()) {
+				t.Errorf("no match TweakPublicKey : %x", ctx.PublicKey())
+			}
+			return nil
+		})
+	}
+}
+
+// musig2 signs a message with a session of random signers, after applying tweak unless it is nil.
+func musig2(t *testing.T, tweak func(ctx *bipschnorr.KeyAggContext) error) {
+	u := rndi(5) + 1
+	m := rndbs()
+	keys := map[string]*bipschnorr.PrivateKey{}
+	Ps := []*bipschnorr.Point{}
+	for i := 0; i < u; i++ {
+		k, _ := bipschnorr.GenerateKey(rand.Reader)
+		P := k.PublicKey().P
+		keys[string(P.Bytes())] = k
+		Ps = append(Ps, P)
+	}
+	Ps = bipschnorr.KeySort(Ps)
+	ctx, err := bipschnorr.KeyAgg(Ps)
+	if err != nil {
+		t.Fatalf("KeyAgg : %v", err)
+	}
+	if tweak != nil {
+		if err := tweak(ctx); err != nil {
+			t.Fatalf("tweak : %v", err)
+		}
+	}
+
+	// First round: nonces
+	sns := []*bipschnorr.SecNonce{}
+	pubnonces := [][]byte{}
+	for _, P := range Ps {
+		sn, pubnonce, err := bipschnorr.NonceGen(rand.Reader, keys[string(P.Bytes())].D(), P, ctx.PublicKey(), m, nil)
+		if err != nil {
+			t.Fatalf("NonceGen : %v", err)
+		}
+		sns = append(sns, sn)
+		pubnonces = append(pubnonces, pubnonce)
+	}
+	aggnonce, err := bipschnorr.NonceAgg(pubnonces)
+	if err != nil {
+		t.Fatalf("NonceAgg : %v", err)
+	}
+
+	// Second round: partial signatures
+	session, err := bipschnorr.NewSession(ctx, aggnonce, m)
+	if err != nil {
+		t.Fatalf("NewSession : %v", err)
+	}
+	psigs := [][]byte{}
+	for i, P := range Ps {
+		d := keys[string(P.Bytes())].D()
+		psig, err := session.Sign(sns[i], d)
+		if err != nil {
+			t.Fatalf("Sign : %v", err)
+		}
+		if _, err := session.Sign(sns[i], d); err != bipschnorr.ErrSecNonceUsed {
+			t.Errorf("secret nonce reused : %v", err)
+		}
+		if err := session.PartialSigVerify(psig, pubnonces[i], P); err != nil {
+			t.Errorf("PartialSigVerify : %v", err)
+		}
+		if err := session.PartialSigVerify(psig, pubnonces[(i+1)%u], P); u > 1 && err == nil {
+			t.Errorf("PartialSigVerify accepted another signer's nonce")
+		}
+		psigs = append(psigs, psig)
+	}
+	sig, err := session.PartialSigAgg(psigs)
+	if err != nil {
+		t.Fatalf("PartialSigAgg : %v", err)
+	}
+	if !bipschnorr.VerificationBIP340(ctx.PublicKey(), m, sig) || !ctx.VerificationBIP340(m, sig) {
+		t.Errorf("fail VerificationBIP340 : %x", sig)
+	}
+}
+
+func mustHex(s string) []byte {
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return bs
+}
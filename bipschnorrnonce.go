@@ -0,0 +1,91 @@
+package bipschnorr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// NonceFunc derives the secret nonce of a signature. Any nonce function gives valid signatures,
+// but it must never return the same nonce for two messages.
+type NonceFunc interface {
+	// Nonce returns the 32 byte nonce bytes(k) for the secret key bytes(d) and the message m; k is taken mod n.
+	Nonce(d, m []byte) []byte
+}
+
+// DraftNonce is the nonce function of the draft, hash(bytes(d) || m).
+type DraftNonce struct{}
+
+// Nonce implements NonceFunc.
+func (DraftNonce) Nonce(d, m []byte) []byte {
+	return hash(ll(d, m))
+}
+
+// auxNonce is the draft's nonce function with bytes(d) masked by auxiliary random data a,
+// hash(t || m) with t the byte-wise xor of bytes(d) and hash(a), or t = bytes(d) if a is all zero.
+type auxNonce []byte
+
+// Nonce implements NonceFunc.
+func (a auxNonce) Nonce(d, m []byte) []byte {
+	t := append([]byte{}, d...)
+	var z byte
+	for _, b := range a {
+		z |= b
+	}
+	if z != 0 {
+		for i, b := range hash(a) {
+			t[i] ^= b
+		}
+	}
+	return hash(ll(t, m))
+}
+
+// RFC6979Nonce is the deterministic nonce of RFC 6979 section 3.2, HMAC-DRBG with SHA256
+// keyed by bytes(d) and the message, with Extra as the additional data of section 3.6.
+// A 32 byte message is taken as the hash h1, any other message is hashed to h1 = SHA256(m) first.
+type RFC6979Nonce struct {
+	Extra []byte
+}
+
+// Nonce implements NonceFunc. The nonce is in the range 1..n-1.
+func (nf RFC6979Nonce) Nonce(d, m []byte) []byte {
+	// h1 = H(m), unless m is already a 32 byte hash
+	if len(m) != 32 {
+		m = hash(m)
+	}
+	// bits2octets(h1) = int2octets(int(h1) mod n)
+	var h scalar
+	h.setBytes(m)
+	h1 := h.bytes()
+	// V = 0x01 0x01 ... 0x01, K = 0x00 0x00 ... 0x00
+	V := make([]byte, 32)
+	for i := range V {
+		V[i] = 0x01
+	}
+	K := make([]byte, 32)
+	// K = HMAC_K(V || 0x00 || int2octets(x) || bits2octets(h1) || k'), V = HMAC_K(V)
+	K = hmacSHA256(K, V, []byte{0x00}, d, h1, nf.Extra)
+	V = hmacSHA256(K, V)
+	// K = HMAC_K(V || 0x01 || int2octets(x) || bits2octets(h1) || k'), V = HMAC_K(V)
+	K = hmacSHA256(K, V, []byte{0x01}, d, h1, nf.Extra)
+	V = hmacSHA256(K, V)
+	var k scalar
+	for {
+		// T = V = HMAC_K(V); k = bits2int(T)
+		V = hmacSHA256(K, V)
+		if k.setBytes(V) && !k.isZero() {
+			return V
+		}
+		// K = HMAC_K(V || 0x00), V = HMAC_K(V)
+		K = hmacSHA256(K, V, []byte{0x00})
+		V = hmacSHA256(K, V)
+	}
+}
+
+// hmacSHA256 returns HMAC-SHA256 with the key k of the concatenation of bss.
+func hmacSHA256(k []byte, bss ...[]byte) []byte {
+	mac := hmac.New(sha256.New, k)
+	for _, bs := range bss {
+		mac.Write(bs)
+	}
+	return mac.Sum(nil)
+}
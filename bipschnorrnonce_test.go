@@ -0,0 +1,101 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRFC6979Nonce(t *testing.T) {
+	// Deterministic ECDSA nonces for secp256k1 with SHA256, as used by Bitcoin wallets.
+	for _, v := range []struct {
+		d, m, k string
+	}{
+		{"0000000000000000000000000000000000000000000000000000000000000001", "Satoshi Nakamoto",
+			"8F8A276C19F4149656B280621E358CCE24F5F52542772691EE69063B74F15D15"},
+		{"0000000000000000000000000000000000000000000000000000000000000001", "All those moments will be lost in time, like tears in rain. Time to die...",
+			"38AA22D72376B4DBC472E06C3BA403EE0A394DA63FC58D88686C611ABA98D6B3"},
+		{"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364140", "Satoshi Nakamoto",
+			"33A19B60E25FB6F4435AF53A3D42D493644827367E6453928554F43E49AA6F90"},
+	} {
+		d, _ := hex.DecodeString(v.d)
+		m := sha256.Sum256([]byte(v.m))
+		k := bipschnorr.RFC6979Nonce{}.Nonce(d, m[:])
+		if !strings.EqualFold(hex.EncodeToString(k), v.k) {
+			t.Errorf("no match nonce : %x", k)
+		}
+		// Any other message length is hashed first.
+		k = bipschnorr.RFC6979Nonce{}.Nonce(d, []byte(v.m))
+		if !strings.EqualFold(hex.EncodeToString(k), v.k) {
+			t.Errorf("no match nonce for the message : %x", k)
+		}
+	}
+}
+
+func TestSigningWithNonce(t *testing.T) {
+	d := rndbi()
+	P := bipschnorr.NewPoint(d)
+	m := rndbs()
+	if !reflect.DeepEqual(bipschnorr.SigningWithNonce(d, m, bipschnorr.DraftNonce{}), bipschnorr.Signing(d, m)) {
+		t.Errorf("no match DraftNonce")
+	}
+	sig1 := bipschnorr.SigningWithNonce(d, m, bipschnorr.RFC6979Nonce{})
+	sig2 := bipschnorr.SigningWithNonce(d, m, bipschnorr.RFC6979Nonce{Extra: []byte{1}})
+	for _, sig := range [][]byte{sig1, sig2} {
+		if err := bipschnorr.Verify(P, m, sig); err != nil {
+			t.Errorf("Verify : %v", err)
+		}
+	}
+	if reflect.DeepEqual(sig1, sig2) {
+		t.Errorf("extra data not used")
+	}
+
+	// Muser takes its nonce from the nonce function.
+	u, _ := bipschnorr.NewMultiUser(1, 2, d, m)
+	if err := u.SetNonceFunc(bipschnorr.RFC6979Nonce{}); err != nil {
+		t.Fatalf("SetNonceFunc : %v", err)
+	}
+	k := new(big.Int).SetBytes(bipschnorr.RFC6979Nonce{}.Nonce(d.FillBytes(make([]byte, 32)), m))
+	if !u.RandomPoint().Equal(bipschnorr.NewPoint(k)) {
+		t.Errorf("no match RandomPoint")
+	}
+
+	// A short message does not fit bits2octets as it is.
+	u, _ = bipschnorr.NewMultiUser(1, 2, d, []byte("short"))
+	if err := u.SetNonceFunc(bipschnorr.RFC6979Nonce{}); err != nil {
+		t.Fatalf("SetNonceFunc : %v", err)
+	}
+	if u.RandomPoint() == nil {
+		t.Errorf("no RandomPoint for a short message")
+	}
+}
+
+// zeroNonce is a broken nonce function which always returns k = 0.
+type zeroNonce struct{}
+
+func (zeroNonce) Nonce(d, m []byte) []byte {
+	return make([]byte, 32)
+}
+
+func TestSigningZeroNonce(t *testing.T) {
+	d := rndbi()
+	m := rndbs()
+	if sig := bipschnorr.SigningWithNonce(d, m, zeroNonce{}); sig != nil {
+		t.Errorf("signed with k = 0 : %x", sig)
+	}
+	if sig := bipschnorr.Signing(big.NewInt(0), m); sig != nil {
+		t.Errorf("signed with d = 0 : %x", sig)
+	}
+
+	u, _ := bipschnorr.NewMultiUser(1, 1, d, m)
+	u.SetPublicKey(1, u.PublicKey())
+	u.SetNonceFunc(zeroNonce{})
+	if _, err := u.Sign(); err != bipschnorr.ErrNonceZero {
+		t.Errorf("Sign : %v", err)
+	}
+}
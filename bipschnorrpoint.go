@@ -0,0 +1,425 @@
+package bipschnorr
+
+// affinePoint is a point in affine coordinates over fieldVal.
+type affinePoint struct {
+	x, y fieldVal
+	inf  bool
+}
+
+// affine returns P as an affinePoint.
+func (P *Point) affine() affinePoint {
+	var a affinePoint
+	if infinite(P) {
+		a.inf = true
+		return a
+	}
+	a.x.setInt(x(P))
+	a.y.setInt(y(P))
+	return a
+}
+
+// jacobian returns P as a jacobianPoint.
+func (P *Point) jacobian() jacobianPoint {
+	var J jacobianPoint
+	a := P.affine()
+	J.setAffine(&a)
+	return J
+}
+
+// point returns a as a Point.
+func (a *affinePoint) point() *Point {
+	if a.inf {
+		return &Point{}
+	}
+	return &Point{a.x.bigInt(), a.y.bigInt()}
+}
+
+// onCurve returns whether a is on the curve and not infinite.
+func (a *affinePoint) onCurve() bool {
+	if a.inf {
+		return false
+	}
+	// y^2 = x^3 + 7
+	var l, r fieldVal
+	l.sqr(&a.y)
+	r.sqr(&a.x).mul(&r, &a.x).add(&r, &fieldVal{7, 0, 0, 0})
+	return l.equal(&r)
+}
+
+// cmov sets r = a if flag is true, without branching on flag.
+func (r *affinePoint) cmov(a *affinePoint, flag bool) {
+	r.x.cmov(&a.x, flag)
+	r.y.cmov(&a.y, flag)
+	m := -b2u(flag)
+	r.inf = (b2u(r.inf)&^m)|(b2u(a.inf)&m) == 1
+}
+
+// batchAffine converts js to affine coordinates with a single field inversion (Montgomery's trick).
+func batchAffine(js []jacobianPoint) []affinePoint {
+	as := make([]affinePoint, len(js))
+	zs := make([]fieldVal, len(js))
+	acc := fieldOne
+	for i := range js {
+		zs[i] = acc
+		if !js[i].isInfinity() {
+			acc.mul(&acc, &js[i].z)
+		}
+	}
+	var inv, zi, zi2 fieldVal
+	inv.inv(&acc)
+	for i := len(js) - 1; i >= 0; i-- {
+		if js[i].isInfinity() {
+			as[i].inf = true
+			continue
+		}
+		zi.mul(&inv, &zs[i])
+		inv.mul(&inv, &js[i].z)
+		zi2.sqr(&zi)
+		as[i].x.mul(&js[i].x, &zi2)
+		as[i].y.mul(&js[i].y, &zi2).mul(&as[i].y, &zi)
+	}
+	return as
+}
+
+// jacobianPoint is a point in Jacobian coordinates, (x, y, z) standing for (x / z^2, y / z^3).
+// Any z = 0 is the point at infinity, so the zero value is infinity.
+type jacobianPoint struct {
+	x, y, z fieldVal
+}
+
+// setAffine sets r = a.
+func (r *jacobianPoint) setAffine(a *affinePoint) *jacobianPoint {
+	if a.inf {
+		*r = jacobianPoint{}
+		return r
+	}
+	r.x, r.y, r.z = a.x, a.y, fieldOne
+	return r
+}
+
+// affine returns r in affine coordinates, which costs one field inversion.
+func (r *jacobianPoint) affine() affinePoint {
+	var a affinePoint
+	if r.isInfinity() {
+		a.inf = true
+		return a
+	}
+	var zi, zi2 fieldVal
+	zi.inv(&r.z)
+	zi2.sqr(&zi)
+	a.x.mul(&r.x, &zi2)
+	a.y.mul(&r.y, &zi2).mul(&a.y, &zi)
+	return a
+}
+
+// point returns r as a Point.
+func (r *jacobianPoint) point() *Point {
+	a := r.affine()
+	return a.point()
+}
+
+// isInfinity returns whether r is the point at infinity.
+func (r *jacobianPoint) isInfinity() bool {
+	return r.z.isZero()
+}
+
+// equal returns whether r and a are the same point.
+func (r *jacobianPoint) equal(a *jacobianPoint) bool {
+	if r.isInfinity() || a.isInfinity() {
+		return r.isInfinity() == a.isInfinity()
+	}
+	// x1 * z2^2 = x2 * z1^2 and y1 * z2^3 = y2 * z1^3
+	var z1z1, z2z2, u1, u2, s1, s2 fieldVal
+	z1z1.sqr(&r.z)
+	z2z2.sqr(&a.z)
+	u1.mul(&r.x, &z2z2)
+	u2.mul(&a.x, &z1z1)
+	s1.mul(&r.y, &z2z2).mul(&s1, &a.z)
+	s2.mul(&a.y, &z1z1).mul(&s2, &r.z)
+	return u1.equal(&u2) && s1.equal(&s2)
+}
+
+// neg sets r = -a.
+func (r *jacobianPoint) neg(a *jacobianPoint) *jacobianPoint {
+	r.x, r.z = a.x, a.z
+	r.y.neg(&a.y)
+	return r
+}
+
+// cmov sets r = a if flag is true, without branching on flag.
+func (r *jacobianPoint) cmov(a *jacobianPoint, flag bool) {
+	r.x.cmov(&a.x, flag)
+	r.y.cmov(&a.y, flag)
+	r.z.cmov(&a.z, flag)
+}
+
+// double sets r = 2a.
+func (r *jacobianPoint) double(a *jacobianPoint) *jacobianPoint {
+	// http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+	var A, B, C, D, E, F, x3, y3, z3 fieldVal
+	A.sqr(&a.x)
+	B.sqr(&a.y)
+	C.sqr(&B)
+	D.add(&a.x, &B).sqr(&D).sub(&D, &A).sub(&D, &C).add(&D, &D)
+	E.mulInt(&A, 3)
+	F.sqr(&E)
+	x3.sub(&F, &D).sub(&x3, &D)
+	y3.sub(&D, &x3).mul(&y3, &E).sub(&y3, C.mulInt(&C, 8))
+	z3.mul(&a.y, &a.z).add(&z3, &z3)
+	r.x, r.y, r.z = x3, y3, z3
+	return r
+}
+
+// add sets r = a + b.
+func (r *jacobianPoint) add(a, b *jacobianPoint) *jacobianPoint {
+	if a.isInfinity() {
+		*r = *b
+		return r
+	}
+	if b.isInfinity() {
+		*r = *a
+		return r
+	}
+	// http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+	var z1z1, z2z2, u1, u2, s1, s2, h, i, j, rr, v, x3, y3, z3 fieldVal
+	z1z1.sqr(&a.z)
+	z2z2.sqr(&b.z)
+	u1.mul(&a.x, &z2z2)
+	u2.mul(&b.x, &z1z1)
+	s1.mul(&a.y, &b.z).mul(&s1, &z2z2)
+	s2.mul(&b.y, &a.z).mul(&s2, &z1z1)
+	h.sub(&u2, &u1)
+	rr.sub(&s2, &s1)
+	if h.isZero() {
+		if rr.isZero() {
+			return r.double(a)
+		}
+		*r = jacobianPoint{}
+		return r
+	}
+	i.add(&h, &h).sqr(&i)
+	j.mul(&h, &i)
+	rr.add(&rr, &rr)
+	v.mul(&u1, &i)
+	x3.sqr(&rr).sub(&x3, &j).sub(&x3, &v).sub(&x3, &v)
+	s1.mul(&s1, &j).add(&s1, &s1)
+	y3.sub(&v, &x3).mul(&y3, &rr).sub(&y3, &s1)
+	z3.add(&a.z, &b.z).sqr(&z3).sub(&z3, &z1z1).sub(&z3, &z2z2).mul(&z3, &h)
+	r.x, r.y, r.z = x3, y3, z3
+	return r
+}
+
+// addAffine sets r = a + b for an affine b, which saves several multiplications over add.
+func (r *jacobianPoint) addAffine(a *jacobianPoint, b *affinePoint) *jacobianPoint {
+	if b.inf {
+		*r = *a
+		return r
+	}
+	if a.isInfinity() {
+		return r.setAffine(b)
+	}
+	// http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-madd-2007-bl
+	var z1z1, u2, s2, h, hh, i, j, rr, v, x3, y3, z3 fieldVal
+	z1z1.sqr(&a.z)
+	u2.mul(&b.x, &z1z1)
+	s2.mul(&b.y, &a.z).mul(&s2, &z1z1)
+	h.sub(&u2, &a.x)
+	rr.sub(&s2, &a.y)
+	if h.isZero() {
+		if rr.isZero() {
+			return r.double(a)
+		}
+		*r = jacobianPoint{}
+		return r
+	}
+	hh.sqr(&h)
+	i.add(&hh, &hh).add(&i, &i)
+	j.mul(&h, &i)
+	rr.add(&rr, &rr)
+	v.mul(&a.x, &i)
+	x3.sqr(&rr).sub(&x3, &j).sub(&x3, &v).sub(&x3, &v)
+	y3.mul(&a.y, &j)
+	y3.add(&y3, &y3)
+	v.sub(&v, &x3).mul(&v, &rr)
+	y3.sub(&v, &y3)
+	z3.add(&a.z, &h).sqr(&z3).sub(&z3, &z1z1).sub(&z3, &hh)
+	r.x, r.y, r.z = x3, y3, z3
+	return r
+}
+
+// addCT sets r = a + b like add, but without branching on the points: the sum, the doubling
+// and the infinite cases are all computed and the result is selected with cmov.
+func (r *jacobianPoint) addCT(a, b *jacobianPoint) *jacobianPoint {
+	var z1z1, z2z2, u1, u2, s1, s2, h, i, j, rr, v, x3, y3, z3 fieldVal
+	z1z1.sqr(&a.z)
+	z2z2.sqr(&b.z)
+	u1.mul(&a.x, &z2z2)
+	u2.mul(&b.x, &z1z1)
+	s1.mul(&a.y, &b.z).mul(&s1, &z2z2)
+	s2.mul(&b.y, &a.z).mul(&s2, &z1z1)
+	h.sub(&u2, &u1)
+	rr.sub(&s2, &s1)
+	hz, rz := b2u(h.isZero()), b2u(rr.isZero())
+	i.add(&h, &h).sqr(&i)
+	j.mul(&h, &i)
+	rr.add(&rr, &rr)
+	v.mul(&u1, &i)
+	x3.sqr(&rr).sub(&x3, &j).sub(&x3, &v).sub(&x3, &v)
+	s1.mul(&s1, &j).add(&s1, &s1)
+	y3.sub(&v, &x3).mul(&y3, &rr).sub(&y3, &s1)
+	z3.add(&a.z, &b.z).sqr(&z3).sub(&z3, &z1z1).sub(&z3, &z2z2).mul(&z3, &h)
+	sum := jacobianPoint{x3, y3, z3}
+	var dbl jacobianPoint
+	dbl.double(a)
+	// a = b doubles, a = -b gives infinity, and an infinite a or b gives the other point.
+	sum.cmov(&dbl, hz&rz == 1)
+	sum.cmov(&jacobianPoint{}, hz&^rz == 1)
+	sum.cmov(b, a.isInfinity())
+	sum.cmov(a, b.isInfinity())
+	*r = sum
+	return r
+}
+
+// addAffineCT sets r = a + b like addAffine, but without branching on the points: the sum, the doubling
+// and the infinite cases are all computed and the result is selected with cmov.
+func (r *jacobianPoint) addAffineCT(a *jacobianPoint, b *affinePoint) *jacobianPoint {
+	var z1z1, u2, s2, h, hh, i, j, rr, v, x3, y3, z3 fieldVal
+	z1z1.sqr(&a.z)
+	u2.mul(&b.x, &z1z1)
+	s2.mul(&b.y, &a.z).mul(&s2, &z1z1)
+	h.sub(&u2, &a.x)
+	rr.sub(&s2, &a.y)
+	hz, rz := b2u(h.isZero()), b2u(rr.isZero())
+	hh.sqr(&h)
+	i.add(&hh, &hh).add(&i, &i)
+	j.mul(&h, &i)
+	rr.add(&rr, &rr)
+	v.mul(&a.x, &i)
+	x3.sqr(&rr).sub(&x3, &j).sub(&x3, &v).sub(&x3, &v)
+	y3.mul(&a.y, &j)
+	y3.add(&y3, &y3)
+	v.sub(&v, &x3).mul(&v, &rr)
+	y3.sub(&v, &y3)
+	z3.add(&a.z, &h).sqr(&z3).sub(&z3, &z1z1).sub(&z3, &hh)
+	sum := jacobianPoint{x3, y3, z3}
+	var dbl, bj jacobianPoint
+	dbl.double(a)
+	bj.x, bj.y, bj.z = b.x, b.y, fieldOne
+	// a = b doubles, a = -b gives infinity, and an infinite a or b gives the other point.
+	sum.cmov(&dbl, hz&rz == 1)
+	sum.cmov(&jacobianPoint{}, hz&^rz == 1)
+	sum.cmov(&bj, a.isInfinity())
+	sum.cmov(a, b.inf)
+	*r = sum
+	return r
+}
+
+// mul sets r = ka. k is split into k1 + k2λ and k1a + k2φ(a) is computed with fixed 4 bit windows,
+// so the sequence of doublings, table lookups and additions does not depend on k, and the additions are
+// the complete addCT, which treats the infinite table entries and accumulator like any other point.
+func (r *jacobianPoint) mul(k *scalar, a *jacobianPoint) *jacobianPoint {
+	k1, k2, neg1, neg2 := k.split()
+	var a1, a2, t jacobianPoint
+	a1 = *a
+	a2.endo(a)
+	a1.cmov(t.neg(&a1), neg1)
+	a2.cmov(t.neg(&a2), neg2)
+	var tbl1, tbl2 [16]jacobianPoint
+	tbl1[1], tbl2[1] = a1, a2
+	for i := 2; i < 16; i++ {
+		tbl1[i].addCT(&tbl1[i-1], &a1)
+		tbl2[i].endo(&tbl1[i])
+		tbl2[i].cmov(t.neg(&tbl2[i]), neg1 != neg2)
+	}
+	var acc jacobianPoint
+	// Both halves are below 2^129, which 33 windows cover.
+	for i := 32; i >= 0; i-- {
+		acc.double(&acc)
+		acc.double(&acc)
+		acc.double(&acc)
+		acc.double(&acc)
+		w1 := (k1[i/16] >> uint(4*(i%16))) & 0xF
+		w2 := (k2[i/16] >> uint(4*(i%16))) & 0xF
+		for j := range tbl1 {
+			t.cmov(&tbl1[j], uint64(j) == w1)
+		}
+		acc.addCT(&acc, &t)
+		for j := range tbl2 {
+			t.cmov(&tbl2[j], uint64(j) == w2)
+		}
+		acc.addCT(&acc, &t)
+	}
+	*r = acc
+	return r
+}
+
+// addPoint sets r = r + P.
+func (r *jacobianPoint) addPoint(P *Point) *jacobianPoint {
+	a := P.affine()
+	return r.addAffine(r, &a)
+}
+
+// Group operations on Point. Every method sets its receiver to the result and returns it, so points can be reused.
+
+// Add sets r = a + b and returns r.
+func (r *Point) Add(a, b *Point) *Point {
+	J := a.jacobian()
+	return r.setJacobian(J.addPoint(b))
+}
+
+// Sub sets r = a - b and returns r.
+func (r *Point) Sub(a, b *Point) *Point {
+	J := b.jacobian()
+	return r.setJacobian(J.neg(&J).addPoint(a))
+}
+
+// Neg sets r = -a and returns r.
+func (r *Point) Neg(a *Point) *Point {
+	J := a.jacobian()
+	return r.setJacobian(J.neg(&J))
+}
+
+// ScalarMult sets r = ka and returns r. It runs in time independent of k.
+func (r *Point) ScalarMult(k *Scalar, a *Point) *Point {
+	J := a.jacobian()
+	return r.setJacobian(J.mul(&k.k, &J))
+}
+
+// ScalarBaseMult sets r = kG and returns r. It runs in time independent of k.
+func (r *Point) ScalarBaseMult(k *Scalar) *Point {
+	J := gBase.mul(&k.k)
+	return r.setJacobian(&J)
+}
+
+// Equal returns whether P and a are the same point.
+func (P *Point) Equal(a *Point) bool {
+	if P.IsInfinity() || a.IsInfinity() {
+		return P.IsInfinity() == a.IsInfinity()
+	}
+	return x(P).Cmp(x(a)) == 0 && y(P).Cmp(y(a)) == 0
+}
+
+// IsInfinity returns whether P is the point at infinity, which is a Point with no Y coordinate (or a zero one).
+func (P *Point) IsInfinity() bool {
+	return y(P) == nil || y(P).Sign() == 0
+}
+
+// IsOnCurve returns whether P is not nil, not infinite, has coordinates below p and is on the curve.
+func (P *Point) IsOnCurve() bool {
+	if P == nil || P.IsInfinity() || x(P) == nil || x(P).Cmp(p) >= 0 || y(P).Sign() < 0 || y(P).Cmp(p) >= 0 {
+		return false
+	}
+	a := P.affine()
+	return a.onCurve()
+}
+
+// setJacobian sets r = J and returns r.
+func (r *Point) setJacobian(J *jacobianPoint) *Point {
+	a := J.affine()
+	if a.inf {
+		r[0], r[1] = nil, nil
+		return r
+	}
+	r[0], r[1] = a.x.bigInt(), a.y.bigInt()
+	return r
+}
@@ -0,0 +1,136 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestPointArithmetic(t *testing.T) {
+	a, b := rndbi(), rndbi()
+	ka, kb := bipschnorr.NewScalar(a), bipschnorr.NewScalar(b)
+	A, B := bipschnorr.NewPoint(a), bipschnorr.NewPoint(b)
+
+	// (a + b)G = aG + bG
+	S := new(bipschnorr.Point).ScalarBaseMult(new(bipschnorr.Scalar).Add(ka, kb))
+	if !S.Equal(new(bipschnorr.Point).Add(A, B)) {
+		t.Errorf("no match Add")
+	}
+	// (a - b)G = aG - bG
+	D := new(bipschnorr.Point).ScalarBaseMult(new(bipschnorr.Scalar).Sub(ka, kb))
+	if !D.Equal(new(bipschnorr.Point).Sub(A, B)) {
+		t.Errorf("no match Sub")
+	}
+	// b(aG) = (ab)G
+	M := new(bipschnorr.Point).ScalarMult(kb, A)
+	if !M.Equal(new(bipschnorr.Point).ScalarBaseMult(new(bipschnorr.Scalar).Mul(ka, kb))) {
+		t.Errorf("no match ScalarMult")
+	}
+	// aG + -(aG) = infinity
+	N := new(bipschnorr.Point).Neg(A)
+	if !N.IsOnCurve() || N.Equal(A) {
+		t.Errorf("no match Neg")
+	}
+	I := new(bipschnorr.Point).Add(A, N)
+	if !I.IsInfinity() || I.IsOnCurve() || !I.Equal(&bipschnorr.Point{}) {
+		t.Errorf("not infinity : %v", I)
+	}
+	// P + infinity = P, and the receiver may be an operand.
+	if !I.Add(I, A).Equal(A) {
+		t.Errorf("no match Add with infinity")
+	}
+	if !A.IsOnCurve() || !bipschnorr.G.IsOnCurve() {
+		t.Errorf("fail IsOnCurve")
+	}
+	// Test vector 5: not on the curve
+	pubbs, _ := hex.DecodeString("03EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34")
+	if bipschnorr.NewPointForPub(pubbs).IsOnCurve() {
+		t.Errorf("success IsOnCurve")
+	}
+	var P *bipschnorr.Point
+	if P.IsOnCurve() {
+		t.Errorf("success IsOnCurve for nil")
+	}
+}
+
+func TestScalar(t *testing.T) {
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	a, b := rndbi(), rndbi()
+	ka, kb := bipschnorr.NewScalar(a), bipschnorr.NewScalar(b)
+	for _, v := range []struct {
+		name string
+		k    *bipschnorr.Scalar
+		x    *big.Int
+	}{
+		{"Add", new(bipschnorr.Scalar).Add(ka, kb), new(big.Int).Add(a, b)},
+		{"Sub", new(bipschnorr.Scalar).Sub(ka, kb), new(big.Int).Sub(a, b)},
+		{"Mul", new(bipschnorr.Scalar).Mul(ka, kb), new(big.Int).Mul(a, b)},
+		{"Neg", new(bipschnorr.Scalar).Neg(ka), new(big.Int).Neg(a)},
+		{"Inverse", new(bipschnorr.Scalar).Inverse(ka), new(big.Int).ModInverse(a, n)},
+		{"SetUint64", new(bipschnorr.Scalar).SetUint64(7), big.NewInt(7)},
+	} {
+		if v.k.Int().Cmp(new(big.Int).Mod(v.x, n)) != 0 {
+			t.Errorf("no match %s : %x", v.name, v.k.Bytes())
+		}
+	}
+	if !new(bipschnorr.Scalar).Mul(ka, new(bipschnorr.Scalar).Inverse(ka)).Equal(new(bipschnorr.Scalar).SetUint64(1)) {
+		t.Errorf("no match a * a^-1")
+	}
+	if !new(bipschnorr.Scalar).Sub(ka, ka).IsZero() {
+		t.Errorf("no match a - a")
+	}
+	k, err := new(bipschnorr.Scalar).SetBytes(ka.Bytes())
+	if err != nil || !k.Equal(ka) {
+		t.Errorf("SetBytes : %v", err)
+	}
+	if _, err := new(bipschnorr.Scalar).SetBytes(n.Bytes()); err == nil {
+		t.Errorf("SetBytes accepted n")
+	}
+}
+
+func TestScalarBaseMultEdge(t *testing.T) {
+	G := bipschnorr.NewPoint(big.NewInt(1))
+	// Small scalars have zero digits in every row but the first, and kG is checked against repeated addition.
+	P := &bipschnorr.Point{}
+	for k := int64(0); k < 40; k++ {
+		if Q := new(bipschnorr.Point).ScalarBaseMult(new(bipschnorr.Scalar).SetUint64(uint64(k))); !Q.Equal(P) {
+			t.Errorf("no match %dG", k)
+		}
+		P.Add(P, G)
+	}
+	// Scalars with a single nonzero digit in a higher row
+	for i := uint(4); i < 256; i += 36 {
+		k := new(big.Int).Lsh(big.NewInt(3), i)
+		Q := new(bipschnorr.Point).ScalarBaseMult(bipschnorr.NewScalar(k))
+		if !Q.Equal(new(bipschnorr.Point).ScalarMult(bipschnorr.NewScalar(k), G)) {
+			t.Errorf("no match 3*2^%dG", i)
+		}
+	}
+	// (n - 1)G = -G
+	nm1, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364140", 16)
+	if Q := new(bipschnorr.Point).ScalarBaseMult(bipschnorr.NewScalar(nm1)); !Q.Equal(new(bipschnorr.Point).Neg(G)) {
+		t.Errorf("no match (n-1)G")
+	}
+}
+
+func TestScalarMultEdge(t *testing.T) {
+	A := bipschnorr.NewPoint(rndbi())
+	// Small scalars select the infinite table entry in almost every window, and kA is checked against repeated addition.
+	P := &bipschnorr.Point{}
+	for k := int64(0); k < 40; k++ {
+		if Q := new(bipschnorr.Point).ScalarMult(new(bipschnorr.Scalar).SetUint64(uint64(k)), A); !Q.Equal(P) {
+			t.Errorf("no match %dA", k)
+		}
+		P.Add(P, A)
+	}
+	// (n - 1)A = -A and kO = O
+	nm1, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364140", 16)
+	if Q := new(bipschnorr.Point).ScalarMult(bipschnorr.NewScalar(nm1), A); !Q.Equal(new(bipschnorr.Point).Neg(A)) {
+		t.Errorf("no match (n-1)A")
+	}
+	if Q := new(bipschnorr.Point).ScalarMult(bipschnorr.NewScalar(rndbi()), &bipschnorr.Point{}); !Q.IsInfinity() {
+		t.Errorf("no match kO")
+	}
+}
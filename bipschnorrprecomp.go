@@ -0,0 +1,87 @@
+package bipschnorr
+
+import (
+	"math/big"
+	"sync"
+)
+
+// fixedBase is a table of multiples of a fixed base point B, built on first use.
+// Row i holds j * 16^i * B for j = 0..15, so kB costs 64 mixed additions and no doublings.
+// It also holds the odd multiples B, 3B, ..., (2^(fixedBaseWindow-1) - 1)B and their images under the endomorphism
+// for variable time wNAF multiplication.
+type fixedBase struct {
+	once    sync.Once
+	base    *Point
+	table   [][16]affinePoint
+	oddOnce sync.Once
+	odd     []affinePoint
+	oddLam  []affinePoint
+}
+
+// fixedBaseWindow is the wNAF window width for the odd multiples of a fixedBase.
+const fixedBaseWindow = 8
+
+// gBase is the fixedBase for the generator G.
+var gBase = newFixedBase(G)
+
+// newFixedBase returns a fixedBase for B. The table is built by the first multiplication.
+func newFixedBase(B *Point) *fixedBase {
+	return &fixedBase{base: B}
+}
+
+// build fills the table.
+func (f *fixedBase) build() {
+	js := make([]jacobianPoint, 64*16)
+	b := f.base.jacobian()
+	for i := 0; i < 64; i++ {
+		row := js[i*16 : (i+1)*16]
+		row[1] = b
+		for j := 2; j < 16; j++ {
+			row[j].add(&row[j-1], &b)
+		}
+		b.double(&row[8])
+	}
+	as := batchAffine(js)
+	f.table = make([][16]affinePoint, 64)
+	for i := range f.table {
+		copy(f.table[i][:], as[i*16:])
+	}
+}
+
+// mul returns kB. Every row is scanned in full and added with addAffineCT, including zero digits,
+// so neither the memory access pattern nor the sequence of field operations depends on k.
+func (f *fixedBase) mul(k *scalar) jacobianPoint {
+	f.once.Do(f.build)
+	var acc jacobianPoint
+	var t affinePoint
+	for i := 0; i < 64; i++ {
+		w := (k[i/16] >> uint(4*(i%16))) & 0xF
+		for j := range f.table[i] {
+			t.cmov(&f.table[i][j], uint64(j) == w)
+		}
+		acc.addAffineCT(&acc, &t)
+	}
+	return acc
+}
+
+// oddTable returns the odd multiples of B and of φ(B).
+func (f *fixedBase) oddTable() ([]affinePoint, []affinePoint) {
+	f.oddOnce.Do(func() {
+		js := make([]jacobianPoint, 1<<(fixedBaseWindow-2))
+		b := f.base.jacobian()
+		oddMultiples(js, &b)
+		f.odd = batchAffine(js)
+		f.oddLam = make([]affinePoint, len(f.odd))
+		for i := range f.odd {
+			f.oddLam[i].endo(&f.odd[i])
+		}
+	})
+	return f.odd, f.oddLam
+}
+
+// addMulBase sets r = r + xB for the base B of f.
+func (r *jacobianPoint) addMulBase(x *big.Int, f *fixedBase) *jacobianPoint {
+	var k scalar
+	J := f.mul(k.setInt(x))
+	return r.add(r, &J)
+}
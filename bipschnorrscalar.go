@@ -0,0 +1,275 @@
+package bipschnorr
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// scalar is an integer modulo n held in four 64-bit limbs, least significant first.
+// A scalar is always fully reduced and every operation runs in time independent of its value.
+type scalar [4]uint64
+
+// scalarN is n in limbs.
+var scalarN = [4]uint64{0xBFD25E8CD0364141, 0xBAAEDCE6AF48A03B, 0xFFFFFFFFFFFFFFFE, 0xFFFFFFFFFFFFFFFF}
+
+// scalarInvExp is n - 2.
+var scalarInvExp = [4]uint64{0xBFD25E8CD036413F, 0xBAAEDCE6AF48A03B, 0xFFFFFFFFFFFFFFFE, 0xFFFFFFFFFFFFFFFF}
+
+// scalarNInv is -n^-1 mod 2^64 and scalarR2 is 2^512 mod n, the Montgomery multiplication constants.
+var (
+	scalarNInv = montInv(scalarN[0])
+	scalarR2   = func() scalar {
+		var r scalar
+		r.setInt(new(big.Int).Lsh(big.NewInt(1), 512))
+		return r
+	}()
+)
+
+// setBytes sets r to the 32 byte big endian integer b mod n and reports whether b was below n.
+func (r *scalar) setBytes(b []byte) bool {
+	var t [4]uint64
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			t[3-i] = t[3-i]<<8 | uint64(b[i*8+j])
+		}
+	}
+	// n > 2^255, so one conditional subtraction is enough.
+	u, borrow := sub256(&t, &scalarN)
+	*r = scalar(select256(borrow-1, &u, &t))
+	return borrow == 1
+}
+
+// setInt sets r to x mod n.
+func (r *scalar) setInt(x *big.Int) *scalar {
+	if x.Sign() < 0 || x.Cmp(n) >= 0 {
+		x = mod(x, n)
+	}
+	r.setBytes(bytes(x))
+	return r
+}
+
+// setUint sets r to x.
+func (r *scalar) setUint(x uint64) *scalar {
+	*r = scalar{x, 0, 0, 0}
+	return r
+}
+
+// putBytes writes the 32 byte big endian encoding of r to b.
+func (r *scalar) putBytes(b []byte) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[i*8+j] = byte(r[3-i] >> uint(56-8*j))
+		}
+	}
+}
+
+// bytes returns the 32 byte big endian encoding of r.
+func (r *scalar) bytes() []byte {
+	bs := make([]byte, 32)
+	r.putBytes(bs)
+	return bs
+}
+
+// bigInt returns r as a big.Int.
+func (r *scalar) bigInt() *big.Int {
+	return intbs(r.bytes())
+}
+
+// isZero returns whether r is zero.
+func (r *scalar) isZero() bool {
+	return r[0]|r[1]|r[2]|r[3] == 0
+}
+
+// equal returns whether r and a are the same scalar.
+func (r *scalar) equal(a *scalar) bool {
+	return (r[0]^a[0])|(r[1]^a[1])|(r[2]^a[2])|(r[3]^a[3]) == 0
+}
+
+// add sets r = a + b.
+func (r *scalar) add(a, b *scalar) *scalar {
+	s, c := add256((*[4]uint64)(a), (*[4]uint64)(b))
+	t, borrow := sub256(&s, &scalarN)
+	*r = scalar(select256(-(c | (borrow ^ 1)), &t, &s))
+	return r
+}
+
+// sub sets r = a - b.
+func (r *scalar) sub(a, b *scalar) *scalar {
+	s, borrow := sub256((*[4]uint64)(a), (*[4]uint64)(b))
+	m := -borrow
+	t, _ := add256(&s, &[4]uint64{scalarN[0] & m, scalarN[1] & m, scalarN[2] & m, scalarN[3] & m})
+	*r = scalar(t)
+	return r
+}
+
+// neg sets r = -a.
+func (r *scalar) neg(a *scalar) *scalar {
+	return r.sub(&scalar{}, a)
+}
+
+// mul sets r = a * b.
+func (r *scalar) mul(a, b *scalar) *scalar {
+	t := montMul((*[4]uint64)(a), (*[4]uint64)(b), &scalarN, scalarNInv)
+	*r = scalar(montMul(&t, (*[4]uint64)(&scalarR2), &scalarN, scalarNInv))
+	return r
+}
+
+// inv sets r = a^-1 (Fermat's little theorem); the inverse of zero is zero.
+func (r *scalar) inv(a *scalar) *scalar {
+	var tbl [16]scalar
+	tbl[0].setUint(1)
+	tbl[1] = *a
+	for i := 2; i < 16; i++ {
+		tbl[i].mul(&tbl[i-1], a)
+	}
+	var z scalar
+	z.setUint(1)
+	for i := 63; i >= 0; i-- {
+		z.mul(&z, &z)
+		z.mul(&z, &z)
+		z.mul(&z, &z)
+		z.mul(&z, &z)
+		z.mul(&z, &tbl[(scalarInvExp[i/16]>>uint(4*(i%16)))&0xF])
+	}
+	*r = z
+	return r
+}
+
+// cmov sets r = a if flag is true, without branching on flag.
+func (r *scalar) cmov(a *scalar, flag bool) {
+	*r = scalar(select256(-b2u(flag), (*[4]uint64)(a), (*[4]uint64)(r)))
+}
+
+// montMul returns a * b * 2^-256 mod m for a, b < m, where mInv = -m^-1 mod 2^64.
+func montMul(a, b, m *[4]uint64, mInv uint64) [4]uint64 {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		var c, cc uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(a[j], b[i])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j] = lo
+			c = hi
+		}
+		t[4], cc = bits.Add64(t[4], c, 0)
+		t[5] = cc
+		q := t[0] * mInv
+		hi, lo := bits.Mul64(q, m[0])
+		_, cc = bits.Add64(lo, t[0], 0)
+		c = hi + cc
+		for j := 1; j < 4; j++ {
+			hi, lo = bits.Mul64(q, m[j])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j-1] = lo
+			c = hi
+		}
+		t[3], cc = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + cc
+	}
+	s := [4]uint64{t[0], t[1], t[2], t[3]}
+	u, borrow := sub256(&s, m)
+	return select256(-(t[4] | (borrow ^ 1)), &u, &s)
+}
+
+// montInv returns -m^-1 mod 2^64 for an odd m.
+func montInv(m uint64) uint64 {
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - m*inv
+	}
+	return -inv
+}
+
+// Scalar is an integer modulo n, for arithmetic on secret keys, nonces and tweaks.
+// The zero value is zero. Every method sets its receiver to the result and returns it,
+// and runs in time independent of the values.
+type Scalar struct {
+	k scalar
+}
+
+// NewScalar returns x mod n.
+func NewScalar(x *big.Int) *Scalar {
+	return new(Scalar).SetInt(x)
+}
+
+// SetInt sets r = x mod n.
+func (r *Scalar) SetInt(x *big.Int) *Scalar {
+	r.k.setInt(x)
+	return r
+}
+
+// SetUint64 sets r = x.
+func (r *Scalar) SetUint64(x uint64) *Scalar {
+	r.k.setUint(x)
+	return r
+}
+
+// SetBytes sets r = int(b) for a 32 byte array b, failing if int(b) ≥ n.
+func (r *Scalar) SetBytes(b []byte) (*Scalar, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("scalar must be 32 bytes, got %d", len(b))
+	}
+	var k scalar
+	if !k.setBytes(b) {
+		return nil, fmt.Errorf("scalar not below n")
+	}
+	r.k = k
+	return r, nil
+}
+
+// Bytes returns bytes(r).
+func (r *Scalar) Bytes() []byte {
+	return r.k.bytes()
+}
+
+// Int returns r as a big.Int.
+func (r *Scalar) Int() *big.Int {
+	return r.k.bigInt()
+}
+
+// Add sets r = a + b mod n.
+func (r *Scalar) Add(a, b *Scalar) *Scalar {
+	r.k.add(&a.k, &b.k)
+	return r
+}
+
+// Sub sets r = a - b mod n.
+func (r *Scalar) Sub(a, b *Scalar) *Scalar {
+	r.k.sub(&a.k, &b.k)
+	return r
+}
+
+// Mul sets r = a * b mod n.
+func (r *Scalar) Mul(a, b *Scalar) *Scalar {
+	r.k.mul(&a.k, &b.k)
+	return r
+}
+
+// Neg sets r = -a mod n.
+func (r *Scalar) Neg(a *Scalar) *Scalar {
+	r.k.neg(&a.k)
+	return r
+}
+
+// Inverse sets r = a^-1 mod n; the inverse of zero is zero.
+func (r *Scalar) Inverse(a *Scalar) *Scalar {
+	r.k.inv(&a.k)
+	return r
+}
+
+// IsZero returns whether r is zero.
+func (r *Scalar) IsZero() bool {
+	return r.k.isZero()
+}
+
+// Equal returns whether r and a are the same scalar.
+func (r *Scalar) Equal(a *Scalar) bool {
+	return r.k.equal(&a.k)
+}
@@ -0,0 +1,93 @@
+package bipschnorr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+)
+
+// ErrClosed is returned by a session which has been closed and no longer holds its secrets.
+var ErrClosed = errors.New("session closed")
+
+// redacted is what a secret prints as.
+const redacted = "REDACTED"
+
+// SecretScalar is a secret integer modulo n, such as a secret key, a share or a nonce.
+// It prints redacted with every fmt verb and slog handler, and Destroy overwrites it once it is no longer needed.
+type SecretScalar struct {
+	k scalar
+}
+
+// NewSecretScalar returns x mod n as a secret. x is copied and left as is.
+func NewSecretScalar(x *big.Int) *SecretScalar {
+	s := &SecretScalar{}
+	s.k.setInt(x)
+	return s
+}
+
+// Scalar returns a copy of s for arithmetic.
+func (s *SecretScalar) Scalar() *Scalar {
+	return &Scalar{k: s.k}
+}
+
+// Bytes returns bytes(s).
+func (s *SecretScalar) Bytes() []byte {
+	return s.k.bytes()
+}
+
+// Int returns s as a big.Int, which is not wiped by Destroy.
+func (s *SecretScalar) Int() *big.Int {
+	return s.k.bigInt()
+}
+
+// IsZero returns whether s is zero, which it is after Destroy.
+func (s *SecretScalar) IsZero() bool {
+	return s.k.isZero()
+}
+
+// Destroy overwrites s with zero.
+func (s *SecretScalar) Destroy() {
+	s.k = scalar{}
+}
+
+// String implements fmt.Stringer without revealing s.
+func (s SecretScalar) String() string {
+	return redacted
+}
+
+// GoString implements fmt.GoStringer without revealing s.
+func (s SecretScalar) GoString() string {
+	return "bipschnorr.SecretScalar{" + redacted + "}"
+}
+
+// Format implements fmt.Formatter, so every verb and flag, %d and %x included, prints redacted.
+func (s SecretScalar) Format(f fmt.State, verb rune) {
+	io.WriteString(f, redacted)
+}
+
+// LogValue implements slog.LogValuer without revealing s.
+func (s SecretScalar) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// wipeInt overwrites the words of x, including unused capacity, and sets x to zero.
+func wipeInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	ws := x.Bits()
+	ws = ws[:cap(ws)]
+	for i := range ws {
+		ws[i] = 0
+	}
+	x.SetInt64(0)
+}
+
+// wipeInts wipes every integer of xs.
+func wipeInts(xs []*big.Int) {
+	for _, x := range xs {
+		wipeInt(x)
+	}
+}
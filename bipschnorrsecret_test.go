@@ -0,0 +1,87 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestSecretScalar(t *testing.T) {
+	d := rndbi()
+	s := bipschnorr.NewSecretScalar(d)
+	if s.Int().Cmp(d) != 0 {
+		t.Fatalf("no match secret : %x", s.Bytes())
+	}
+	k, _ := bipschnorr.NewPrivateKey(d)
+	sn, _, err := bipschnorr.NonceGen(rand.Reader, d, bipschnorr.NewPoint(d), nil, rndbs(), nil)
+	if err != nil {
+		t.Fatalf("NonceGen : %v", err)
+	}
+	hexd := fmt.Sprintf("%x", d)
+	for _, f := range []string{"%v", "%+v", "%#v", "%s", "%d", "%x", "%X", "%q", "%08d"} {
+		for _, v := range []interface{}{s, *s, k, *k, sn, *sn} {
+			if out := fmt.Sprintf(f, v); out != "REDACTED" {
+				t.Errorf("not redacted by %s : %s", f, out)
+			}
+		}
+		out := fmt.Sprintf(f, struct{ S bipschnorr.SecretScalar }{*s})
+		if strings.Contains(out, hexd) || strings.Contains(out, d.String()) {
+			t.Errorf("leaked by %s : %s", f, out)
+		}
+	}
+	// slog calls MarshalText and MarshalJSON unless the value is a LogValuer.
+	for _, newHandler := range []func(io.Writer) slog.Handler{
+		func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, nil) },
+		func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, nil) },
+	} {
+		var buf strings.Builder
+		slog.New(newHandler(&buf)).Info("secrets", "s", s, "k", k, "kv", *k, "sn", sn)
+		out := strings.ToLower(buf.String())
+		if strings.Contains(out, hexd) || strings.Count(out, "redacted") != 4 {
+			t.Errorf("leaked by slog : %s", out)
+		}
+	}
+
+	s.Destroy()
+	if !s.IsZero() {
+		t.Errorf("not destroyed : %x", s.Bytes())
+	}
+	if d.Sign() == 0 {
+		t.Errorf("destroyed the source")
+	}
+	k.Destroy()
+	if _, err := k.Sign(nil, rndbs(), nil); err == nil {
+		t.Errorf("signed with a destroyed key")
+	}
+}
+
+func TestMultiUserClose(t *testing.T) {
+	d := rndbi()
+	m := rndbs()
+	u, _ := bipschnorr.NewMultiUser(1, 1, d, m)
+	u.SetPublicKey(1, u.PublicKey())
+	sig, err := u.Signing()
+	if err != nil {
+		t.Fatalf("Signing : %v", err)
+	}
+	u.Close()
+	if d.Cmp(big.NewInt(0)) == 0 {
+		t.Errorf("wiped the caller's key")
+	}
+	if u.RandomPoint() != nil || u.Hash() != nil {
+		t.Errorf("nonce available after Close")
+	}
+	if _, err := u.Sign(); err != bipschnorr.ErrClosed {
+		t.Errorf("Sign : %v", err)
+	}
+	P, _ := u.P()
+	if !bipschnorr.Verification(P, m, sig) {
+		t.Errorf("fail verify : %x", sig)
+	}
+}
@@ -8,35 +8,43 @@ import (
 
 // Tuser is
 type Tuser struct {
-	k    int
-	t    int
-	i    int
-	H    *Point
-	m    []byte
-	a    []*big.Int
-	ad   []*big.Int
-	As   [][]*Point
-	Cs   [][]*Point
-	ss   []*big.Int
-	sds  []*big.Int
-	ts   []int
-	b    []*big.Int
-	bd   []*big.Int
-	Bs   [][]*Point
-	Cds  [][]*Point
-	rs   []*big.Int
-	rds  []*big.Int
-	sigs []*big.Int
+	k     int
+	t     int
+	i     int
+	H     *Point
+	hBase *fixedBase
+	m     []byte
+	a     []*big.Int
+	ad    []*big.Int
+	As    [][]*Point
+	Cs    [][]*Point
+	ss    []*big.Int
+	sds   []*big.Int
+	ts    []int
+	b     []*big.Int
+	bd    []*big.Int
+	Bs    [][]*Point
+	Cds   [][]*Point
+	rs    []*big.Int
+	rds   []*big.Int
+	sigs  []*big.Int
+
+	tweaked bool
+	root    []byte
+	closed  bool
 }
 
 // NewThresholdUser returns Tuser
 func NewThresholdUser(k, t, i int, H *Point) (*Tuser, error) {
-	// TODO validate parameters
+	if t < 1 || k < t || i < 1 || k < i || H == nil {
+		return nil, fmt.Errorf("illegal parameter")
+	}
 	user := &Tuser{}
 	user.k = k
 	user.t = t
 	user.i = i
 	user.H = H
+	user.hBase = newFixedBase(H)
 	user.Cs = make([][]*Point, k)
 	user.ss = make([]*big.Int, k)
 	user.sds = make([]*big.Int, k)
@@ -54,41 +62,53 @@ func (user *Tuser) Idx() int {
 	return user.i
 }
 
-// SharedCommitments returns commitments of shared secret.
-func (user *Tuser) SharedCommitments() []*Point {
+// SharedCommitments returns commitments of shared secret, failing if random numbers can not be read.
+func (user *Tuser) SharedCommitments() ([]*Point, error) {
 	if user.Cs[user.i-1] != nil {
-		return user.Cs[user.i-1]
+		return user.Cs[user.i-1], nil
 	}
 	// a_{i0} ... a_{i(t-1)}
 	// a'_{i0} ... a'_{i(t-1)}
+	as, err := rnds(user.t)
+	if err != nil {
+		return nil, err
+	}
+	ads, err := rnds(user.t)
+	if err != nil {
+		wipeInts(as)
+		return nil, err
+	}
+	user.a = as
+	user.ad = ads
 	Cs := []*Point{}
 	for i := 0; i < user.t; i++ {
-		a := rnd()
-		ad := rnd()
-		user.a = append(user.a, a)
-		user.ad = append(user.ad, ad)
-		// C = aG + a'G
-		C := pointAdd(pointMul(a, G), pointMul(ad, user.H))
-		Cs = append(Cs, C)
+		// C = aG + a'H
+		var C jacobianPoint
+		C.addMulBase(as[i], gBase).addMulBase(ads[i], user.hBase)
+		Cs = append(Cs, C.point())
 	}
 	user.Cs[user.i-1] = Cs
 	// s_{ii} = f_i(x) = a_{i0} + a_{i1}x^1 + ... + a_{i(t-1)}x^{t-1}
 	user.ss[user.i-1] = polynomial(user.i, user.a)
 	// s'_{ii} = f'_i(x) = a'_{i0} + a'_{i1}x^1 + ... + a'_{i(t-1)}x^{t-1}
 	user.sds[user.i-1] = polynomial(user.i, user.ad)
-	return user.Cs[user.i-1]
+	return user.Cs[user.i-1], nil
 }
 
 // SetSharedCommitments sets commitments of shared secret for user(j).
 func (user *Tuser) SetSharedCommitments(j int, C []*Point) error {
-	// TODO validate parameters
+	if !user.valid(j) || j == user.i || !user.points(C) {
+		return fmt.Errorf("illegal parameter")
+	}
 	user.Cs[j-1] = C
 	return nil
 }
 
 // SharedSecret returns shared secret for user(j).
 func (user *Tuser) SharedSecret(j int) (*big.Int, *big.Int) {
-	// TODO validate parameter
+	if user.closed || !user.valid(j) || user.a == nil {
+		return nil, nil
+	}
 	// s_{ij} = f_i(j) = a_{i0} + a_{i1}j^1 + ... + a_{i(t-1)}j^{t-1}
 	s := polynomial(j, user.a)
 	// s'_{ij} = f'_i(j) = a'_{i0} + a'_{i1}j^1 + ... + a'_{i(t-1)}j^{t-1}
@@ -98,7 +118,9 @@ func (user *Tuser) SharedSecret(j int) (*big.Int, *big.Int) {
 
 // OtherSharedCommitments returns other user's commitments of shared secret.
 func (user *Tuser) OtherSharedCommitments(j int) [][]*Point {
-	// TODO validate parameter
+	if !user.valid(j) {
+		return nil
+	}
 	Cs := [][]*Point{}
 	for i, C := range user.Cs {
 		if (user.Idx() == i+1) || (j == i+1) {
@@ -111,15 +133,18 @@ func (user *Tuser) OtherSharedCommitments(j int) [][]*Point {
 
 // SetSharedSecret verifies and sets shared secret for user(j) and other commitments.
 func (user *Tuser) SetSharedSecret(j int, s, sd *big.Int, ocs [][]*Point) error {
-	// TODO validate parameter
+	if !user.valid(j) || j == user.i || s == nil || sd == nil || len(ocs) != user.k-2 {
+		return fmt.Errorf("illegal parameter")
+	}
+	if user.Cs[j-1] == nil {
+		return fmt.Errorf("not received shared commitments from the user(%d)", j)
+	}
 	// sG + s'H
-	sGsdH := pointAdd(pointMul(s, G), pointMul(sd, user.H))
+	var sGsdH jacobianPoint
+	sGsdH.addMulBase(s, gBase).addMulBase(sd, user.hBase)
 	// i^0C_{j0} + ... + i^(t-1)C_{j(t-1)}
-	S := &Point{}
-	for i, C := range user.Cs[j-1] {
-		S = pointAdd(S, pointMul(expn(user.Idx(), i), C))
-	}
-	if !bseq(sGsdH.Bytes(), S.Bytes()) {
+	S := ecmult(nil, powerTerms(user.Idx(), user.Cs[j-1]))
+	if !sGsdH.equal(&S) {
 		return fmt.Errorf("invalid ShareSignature. %d", j)
 	}
 	hi := 0
@@ -127,6 +152,9 @@ func (user *Tuser) SetSharedSecret(j int, s, sd *big.Int, ocs [][]*Point) error
 		if h == user.i || h == j {
 			continue
 		}
+		if user.Cs[h-1] == nil || len(ocs[hi]) != user.t {
+			return fmt.Errorf("illegal other commitment. %d", hi+1)
+		}
 		for i := range ocs[hi] {
 			if !bseq(ocs[hi][i].Bytes(), user.Cs[h-1][i].Bytes()) {
 				return fmt.Errorf("illegal other commitment. %d %d", hi+1, i)
@@ -134,8 +162,9 @@ func (user *Tuser) SetSharedSecret(j int, s, sd *big.Int, ocs [][]*Point) error
 		}
 		hi++
 	}
-	user.ss[j-1] = s
-	user.sds[j-1] = sd
+	// Keep copies, so Close wipes only what the user holds.
+	user.ss[j-1] = new(big.Int).Set(s)
+	user.sds[j-1] = new(big.Int).Set(sd)
 	return nil
 }
 
@@ -148,7 +177,7 @@ func (user *Tuser) SharedPoints() []*Point {
 	As := []*Point{}
 	for _, a := range user.a {
 		// A = aG
-		A := pointMul(a, G)
+		A := NewPoint(a)
 		As = append(As, A)
 	}
 	user.As[user.i-1] = As
@@ -157,15 +186,18 @@ func (user *Tuser) SharedPoints() []*Point {
 
 // SetSharedPoints verifies and sets shared points.
 func (user *Tuser) SetSharedPoints(j int, As []*Point) error {
-	// TODO validate parameter
+	if !user.valid(j) || j == user.i || !user.points(As) {
+		return fmt.Errorf("illegal parameter")
+	}
+	if user.ss[j-1] == nil {
+		return fmt.Errorf("not received shared secret from the user(%d)", j)
+	}
 	// sG
-	sG := pointMul(user.ss[j-1], G)
+	var sG jacobianPoint
+	sG.addMulBase(user.ss[j-1], gBase)
 	// i^0A_{j0} + ... + i^(t-1)A_{j(t-1)}
-	sum := &Point{}
-	for i, A := range As {
-		sum = pointAdd(sum, pointMul(expn(user.Idx(), i), A))
-	}
-	if !bseq(sG.Bytes(), sum.Bytes()) {
+	sum := ecmult(nil, powerTerms(user.Idx(), As))
+	if !sG.equal(&sum) {
 		return fmt.Errorf("invalid shared publickeys. %d", j)
 	}
 	user.As[j-1] = As
@@ -174,20 +206,32 @@ func (user *Tuser) SetSharedPoints(j int, As []*Point) error {
 
 // SharedPublickey returns shared publickey.
 func (user *Tuser) SharedPublickey() *Point {
-	pub := &Point{}
+	var pub jacobianPoint
 	// P = A_{10} + ... + A_{k0}
 	for _, a := range user.As {
 		if len(a) == 0 {
 			return nil
 		}
-		pub = pointAdd(pub, a[0])
+		pub.addPoint(a[0])
 	}
-	return pub
+	return pub.point()
 }
 
 // SetSigners sets signers.
 func (user *Tuser) SetSigners(ts []int) error {
-	// TODO validate parameter
+	if len(ts) != user.t {
+		return fmt.Errorf("illegal parameter")
+	}
+	for i, t := range ts {
+		if !user.valid(t) {
+			return fmt.Errorf("illegal parameter")
+		}
+		for _, u := range ts[:i] {
+			if t == u {
+				return fmt.Errorf("duplicate signer(%d)", t)
+			}
+		}
+	}
 	user.ts = ts
 	// if len(ts) == 1 {
 	// 	user.RandomCommitments()
@@ -208,35 +252,45 @@ func (user *Tuser) sidx(j int) int {
 	return idx
 }
 
-// RandomCommitments returns commitments of random point.
-func (user *Tuser) RandomCommitments() []*Point {
+// RandomCommitments returns commitments of random point, failing if random numbers can not be read.
+func (user *Tuser) RandomCommitments() ([]*Point, error) {
 	i := user.sidx(user.i)
 	if i < 0 {
-		return nil
+		return nil, fmt.Errorf("not found user(%d)", user.i)
 	}
 	if user.Cds[i] != nil {
-		return user.Cds[i]
+		return user.Cds[i], nil
 	}
 	// b_{i_u0} ... b_{i_u(t-1)}
 	// b'_{i_u0} ... b'_{i_u(t-1)}
+	bs, err := rnds(user.t)
+	if err != nil {
+		return nil, err
+	}
+	bds, err := rnds(user.t)
+	if err != nil {
+		wipeInts(bs)
+		return nil, err
+	}
+	user.b = bs
+	user.bd = bds
 	Cds := []*Point{}
-	for i := 0; i < user.t; i++ {
-		b := rnd()
-		bd := rnd()
-		user.b = append(user.b, b)
-		user.bd = append(user.bd, bd)
-		Cd := pointAdd(pointMul(b, G), pointMul(bd, user.H))
-		Cds = append(Cds, Cd)
+	for j := 0; j < user.t; j++ {
+		var Cd jacobianPoint
+		Cd.addMulBase(bs[j], gBase).addMulBase(bds[j], user.hBase)
+		Cds = append(Cds, Cd.point())
 	}
 	user.Cds[i] = Cds
 	user.rs[i] = polynomial(user.i, user.b)
 	user.rds[i] = polynomial(user.i, user.bd)
-	return user.Cds[i]
+	return user.Cds[i], nil
 }
 
 // SetRandomCommitments sets commitments of random point for user(j).
 func (user *Tuser) SetRandomCommitments(j int, Cd []*Point) error {
-	// TODO validate parameters
+	if j == user.i || !user.points(Cd) {
+		return fmt.Errorf("illegal parameter")
+	}
 	idx := user.sidx(j)
 	if idx < 0 {
 		return fmt.Errorf("not found user(%d)", j)
@@ -247,7 +301,9 @@ func (user *Tuser) SetRandomCommitments(j int, Cd []*Point) error {
 
 // RandomNumber returns random number for user(j).
 func (user *Tuser) RandomNumber(j int) (*big.Int, *big.Int) {
-	// TODO validate parameter
+	if user.closed || user.sidx(j) < 0 || user.b == nil {
+		return nil, nil
+	}
 	r := polynomial(j, user.b)
 	rd := polynomial(j, user.bd)
 	return r, rd
@@ -255,7 +311,9 @@ func (user *Tuser) RandomNumber(j int) (*big.Int, *big.Int) {
 
 // OtherRandomCommitments gets other commitments of shared publickey.
 func (user *Tuser) OtherRandomCommitments(j int) [][]*Point {
-	// TODO validate parameter
+	if user.sidx(j) < 0 {
+		return nil
+	}
 	cds := [][]*Point{}
 	for i, t := range user.ts {
 		if (user.Idx() == t) || (j == t) {
@@ -268,19 +326,22 @@ func (user *Tuser) OtherRandomCommitments(j int) [][]*Point {
 
 // SetRandomNumber a
 func (user *Tuser) SetRandomNumber(j int, r, rd *big.Int, ocds [][]*Point) error {
-	// TODO validate parameters
+	if j == user.i || r == nil || rd == nil || len(ocds) != len(user.ts)-2 {
+		return fmt.Errorf("illegal parameter")
+	}
 	idx := user.sidx(j)
 	if idx < 0 {
-		return nil
+		return fmt.Errorf("not found user(%d)", j)
+	}
+	if user.Cds[idx] == nil {
+		return fmt.Errorf("not received random commitments from the user(%d)", j)
 	}
 	// rG + r'H
-	rGrdH := pointAdd(pointMul(r, G), pointMul(rd, user.H))
+	var rGrdH jacobianPoint
+	rGrdH.addMulBase(r, gBase).addMulBase(rd, user.hBase)
 	// i^0C_{j0} + ... + i^(t-1)C_{j(t-1)}
-	sum := &Point{}
-	for i, Cd := range user.Cds[idx] {
-		sum = pointAdd(sum, pointMul(expn(user.Idx(), i), Cd))
-	}
-	if !bseq(rGrdH.Bytes(), sum.Bytes()) {
+	sum := ecmult(nil, powerTerms(user.Idx(), user.Cds[idx]))
+	if !rGrdH.equal(&sum) {
 		return fmt.Errorf("invalid RandomNumber. %d", j)
 	}
 	oi := 0
@@ -288,6 +349,9 @@ func (user *Tuser) SetRandomNumber(j int, r, rd *big.Int, ocds [][]*Point) error
 		if t == user.i || t == j {
 			continue
 		}
+		if user.Cds[h] == nil || len(ocds[oi]) != user.t {
+			return fmt.Errorf("illegal other commitment. %d", oi+1)
+		}
 		for i := range ocds[oi] {
 			if !bseq(ocds[oi][i].Bytes(), user.Cds[h][i].Bytes()) {
 				return fmt.Errorf("illegal other commitment. %d %d", oi+1, t)
@@ -295,8 +359,8 @@ func (user *Tuser) SetRandomNumber(j int, r, rd *big.Int, ocds [][]*Point) error
 		}
 		oi++
 	}
-	user.rs[idx] = r
-	user.rds[idx] = rd
+	user.rs[idx] = new(big.Int).Set(r)
+	user.rds[idx] = new(big.Int).Set(rd)
 	return nil
 }
 
@@ -311,7 +375,7 @@ func (user *Tuser) RandomPoints() []*Point {
 	}
 	Bs := []*Point{}
 	for _, b := range user.b {
-		B := pointMul(b, G)
+		B := NewPoint(b)
 		Bs = append(Bs, B)
 	}
 	user.Bs[i] = Bs
@@ -320,19 +384,22 @@ func (user *Tuser) RandomPoints() []*Point {
 
 // SetRandomPoints verifies and sets random points.
 func (user *Tuser) SetRandomPoints(j int, Bs []*Point) error {
-	// TODO validate parameters
+	if j == user.i || !user.points(Bs) {
+		return fmt.Errorf("illegal parameter")
+	}
 	idx := user.sidx(j)
 	if idx < 0 {
-		return nil
+		return fmt.Errorf("not found user(%d)", j)
+	}
+	if user.rs[idx] == nil {
+		return fmt.Errorf("not received random number from the user(%d)", j)
 	}
 	// rG
-	rG := pointMul(user.rs[idx], G)
+	var rG jacobianPoint
+	rG.addMulBase(user.rs[idx], gBase)
 	// i^0A_{j0} + ... + i^(t-1)A_{j(t-1)}
-	sum := &Point{}
-	for i, B := range Bs {
-		sum = pointAdd(sum, pointMul(expn(user.Idx(), i), B))
-	}
-	if !bseq(rG.Bytes(), sum.Bytes()) {
+	sum := ecmult(nil, powerTerms(user.Idx(), Bs))
+	if !rG.equal(&sum) {
 		return fmt.Errorf("invalid shared publickeys. %d", j)
 	}
 	user.Bs[idx] = Bs
@@ -341,41 +408,66 @@ func (user *Tuser) SetRandomPoints(j int, Bs []*Point) error {
 
 // RandomPoint returns random point.
 func (user *Tuser) RandomPoint() *Point {
-	pub := &Point{}
+	var pub jacobianPoint
 	for _, b := range user.Bs {
 		if len(b) == 0 {
 			return nil
 		}
-		pub = pointAdd(pub, b[0])
+		pub.addPoint(b[0])
 	}
-	return pub
+	return pub.point()
 }
 
 // SetMessage sets message.
-func (user *Tuser) SetMessage(m []byte) {
-	// TODO validate parameter
+func (user *Tuser) SetMessage(m []byte) error {
+	if m == nil {
+		return fmt.Errorf("illegal parameter")
+	}
 	user.m = m
+	return nil
 }
 
 // Signature returns signature.
 func (user *Tuser) Signature() *big.Int {
-	// TODO check internal variable
-	i := user.Idx()
-	k := big.NewInt(0)
-	for _, r := range user.rs {
-		k = mod(add(k, r), n)
+	if user.closed || user.m == nil || user.sidx(user.i) < 0 {
+		return nil
 	}
 	R := user.RandomPoint()
-	if jacobi(y(R)).Cmp(big.NewInt(1)) != 0 {
-		k = sub(n, k)
+	if R == nil {
+		return nil
 	}
-	P := user.SharedPublickey()
-	e := mod(intbs(hash(ll(bytes(x(R)), P.Bytes(), user.m))), n)
-	sig := big.NewInt(0)
-	for _, s := range user.ss {
-		sig = mod(add(sig, s), n)
+	i := user.Idx()
+	var k, r scalar
+	for _, ri := range user.rs {
+		if ri == nil {
+			return nil
+		}
+		k.add(&k, r.setInt(ri))
 	}
-	sig = mod(add(k, mul(e, sig)), n)
+	// Fail if k = 0.
+	if k.isZero() {
+		return nil
+	}
+	Q, neg, _, err := user.key()
+	if err != nil {
+		return nil
+	}
+	e, negk := challenge(R, Q, user.m, user.tweaked)
+	if negk {
+		k.neg(&k)
+	}
+	var d, s scalar
+	for _, si := range user.ss {
+		if si == nil {
+			return nil
+		}
+		d.add(&d, s.setInt(si))
+	}
+	// Q = -P + tG signs with -d.
+	if neg {
+		d.neg(&d)
+	}
+	sig := e.mul(e, &d).add(e, &k).bigInt()
 	idx := user.sidx(i)
 	user.sigs[idx] = sig
 	return sig
@@ -383,32 +475,51 @@ func (user *Tuser) Signature() *big.Int {
 
 // SetSignature verifies and sets signature for user(j).
 func (user *Tuser) SetSignature(j int, sig *big.Int) error {
-	// TODO check internal variable
+	if sig == nil {
+		return fmt.Errorf("illegal parameter")
+	}
+	if user.m == nil {
+		return fmt.Errorf("not set message")
+	}
 	idx := user.sidx(j)
 	if idx < 0 {
 		return fmt.Errorf("not found user(%d)", j)
 	}
-	Bsum := &Point{}
+	R := user.RandomPoint()
+	if R == nil {
+		return fmt.Errorf("not received random points")
+	}
+	// B = Σ j^iB_i, negated with the random point
+	terms := []ecmultTerm{}
 	for _, Bs := range user.Bs {
-		for i, B := range Bs {
-			Bsum = pointAdd(Bsum, pointMul(expn(j, i), B))
+		terms = append(terms, powerTerms(j, Bs)...)
+	}
+	Q, neg, _, err := user.key()
+	if err != nil {
+		return err
+	}
+	e, negk := challenge(R, Q, user.m, user.tweaked)
+	if negk {
+		for i := range terms {
+			terms[i].k.neg(&terms[i].k)
 		}
 	}
-	R := user.RandomPoint()
-	if jacobi(y(R)).Cmp(big.NewInt(1)) != 0 {
-		Bsum = pointMul(mod(big.NewInt(-1), n), Bsum)
+	// Q = -P + tG signs with -A.
+	if neg {
+		e.neg(e)
 	}
-	P := user.SharedPublickey()
-	e := mod(intbs(hash(ll(bytes(x(R)), P.Bytes(), user.m))), n)
-	Asum := &Point{}
+	// eA = Σ ej^iA_i
 	for _, As := range user.As {
-		for i, A := range As {
-			Asum = pointAdd(Asum, pointMul(expn(j, i), A))
+		for _, t := range powerTerms(j, As) {
+			t.k.mul(&t.k, e)
+			terms = append(terms, t)
 		}
 	}
-	gG := pointMul(sig, G)
-	BHA := pointAdd(Bsum, pointMul(e, Asum))
-	if !bseq(gG.Bytes(), BHA.Bytes()) {
+	// sig G - B - eA = 0
+	var g scalar
+	g.setInt(sig).neg(&g)
+	BHA := ecmult(&g, terms)
+	if !BHA.isInfinity() {
 		return fmt.Errorf("invalid signature")
 	}
 	user.sigs[idx] = sig
@@ -416,10 +527,22 @@ func (user *Tuser) SetSignature(j int, sig *big.Int) error {
 }
 
 // Signing returns signature.
-func (user *Tuser) Signing() []byte {
-	// TODO check internal variable
+func (user *Tuser) Signing() ([]byte, error) {
+	if user.ts == nil {
+		return nil, fmt.Errorf("not set signers")
+	}
+	if user.m == nil {
+		return nil, fmt.Errorf("not set message")
+	}
+	R := user.RandomPoint()
+	if R == nil {
+		return nil, fmt.Errorf("not received random points")
+	}
 	s := big.NewInt(0)
 	for j := range user.sigs {
+		if user.sigs[j] == nil {
+			return nil, fmt.Errorf("not received signature from the signer(%d)", user.ts[j])
+		}
 		o := big.NewInt(1)
 		for _, t := range user.ts {
 			if t == user.ts[j] {
@@ -430,8 +553,78 @@ func (user *Tuser) Signing() []byte {
 		}
 		s = mod(add(s, mul(o, user.sigs[j])), n)
 	}
-	R := user.RandomPoint()
-	return ll(bytes(x(R)), bytes(s))
+	// s = Σ λ_j sig_j + et
+	Q, _, t, err := user.key()
+	if err != nil {
+		return nil, err
+	}
+	e, _ := challenge(R, Q, user.m, user.tweaked)
+	s = mod(add(s, mul(e.bigInt(), t.bigInt())), n)
+	sig := ll(bytes(x(R)), bytes(s))
+	// Fail if Verification(Q, m, sig) fails.
+	if !verification(Q, user.m, sig, user.tweaked) {
+		return nil, ErrSigningFault
+	}
+	return sig, nil
+}
+
+// SetTweak makes the signers sign for the Taproot output key TweakPublicKey(P, merkleRoot) instead of the shared public key P.
+func (user *Tuser) SetTweak(merkleRoot []byte) error {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return fmt.Errorf("illegal merkle root")
+	}
+	user.tweaked = true
+	user.root = merkleRoot
+	return nil
+}
+
+// Q returns the key the signature is valid for, TweakPublicKey(P, merkleRoot) after SetTweak and the shared public key P otherwise.
+func (user *Tuser) Q() *Point {
+	Q, _, _, err := user.key()
+	if err != nil {
+		return nil
+	}
+	return Q
+}
+
+// key returns Q = ±P + tG, whether P is negated, and t, which is zero without a tweak.
+func (user *Tuser) key() (*Point, bool, *scalar, error) {
+	P := user.SharedPublickey()
+	if P == nil {
+		return nil, false, nil, fmt.Errorf("not received shared public keys")
+	}
+	if !user.tweaked {
+		return P, false, &scalar{}, nil
+	}
+	return tapTweakEven(P, user.root)
+}
+
+// Close wipes the secret shares and nonces once the signature is produced.
+// The user can not hand out shares or sign after Close.
+func (user *Tuser) Close() {
+	for _, xs := range [][]*big.Int{user.a, user.ad, user.ss, user.sds, user.b, user.bd, user.rs, user.rds} {
+		wipeInts(xs)
+	}
+	user.a, user.ad, user.b, user.bd = nil, nil, nil, nil
+	user.closed = true
+}
+
+// valid returns true if j is an index of the users.
+func (user *Tuser) valid(j int) bool {
+	return 1 <= j && j <= user.k
+}
+
+// points returns true if Ps has t points, a commitment or point for each coefficient.
+func (user *Tuser) points(Ps []*Point) bool {
+	if len(Ps) != user.t {
+		return false
+	}
+	for _, P := range Ps {
+		if P == nil {
+			return false
+		}
+	}
+	return true
 }
 
 // polynomial returns f(x) = as[0]x^0 + as[1]x^1 + ... + as[n-1]x^{n-1}
@@ -443,12 +636,32 @@ func polynomial(x int, as []*big.Int) *big.Int {
 	return y
 }
 
-// rnd returns a byte array of length 32.
-func rnd() *big.Int {
-	bs := make([]byte, 32)
-	rand.Read(bs)
-	r := new(big.Int).Mod(new(big.Int).SetBytes(bs), n)
-	return r
+// powerTerms returns the terms x^0Ps[0], x^1Ps[1], ..., x^{n-1}Ps[n-1].
+func powerTerms(x int, Ps []*Point) []ecmultTerm {
+	terms := make([]ecmultTerm, len(Ps))
+	var xi, xs scalar
+	xi.setUint(1)
+	xs.setUint(uint64(x))
+	for i, P := range Ps {
+		terms[i].k = xi
+		terms[i].P = P.jacobian()
+		xi.mul(&xi, &xs)
+	}
+	return terms
+}
+
+// rnds returns c random integers in the range 1..n-1.
+func rnds(c int) ([]*big.Int, error) {
+	xs := []*big.Int{}
+	for i := 0; i < c; i++ {
+		k, err := randScalar(rand.Reader)
+		if err != nil {
+			wipeInts(xs)
+			return nil, err
+		}
+		xs = append(xs, k.bigInt())
+	}
+	return xs, nil
 }
 
 // expn returns x^y mod n.
@@ -1,6 +1,7 @@
 package bipschnorr_test
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
@@ -8,6 +9,14 @@ import (
 )
 
 func TestThreshold(te *testing.T) {
+	threshold(te, false, nil)
+}
+
+func TestThresholdTweak(te *testing.T) {
+	threshold(te, true, rndbs())
+}
+
+func threshold(te *testing.T, tweak bool, root []byte) {
 	start := time.Now()
 	te.Logf("Introduction / %fs", (time.Now().Sub(start)).Seconds())
 	k := rndi(9) + 2
@@ -22,6 +31,9 @@ func TestThreshold(te *testing.T) {
 			te.Fail()
 			return
 		}
+		if tweak {
+			user.SetTweak(root)
+		}
 		users = append(users, user)
 	}
 	te.Logf("The number of users k. %d", k)
@@ -31,7 +43,12 @@ func TestThreshold(te *testing.T) {
 	te.Logf("Shared Secret / %fs", (time.Now().Sub(start)).Seconds())
 	te.Logf("Step 1 / %fs", (time.Now().Sub(start)).Seconds())
 	for _, ui := range users {
-		C := ui.SharedCommitments()
+		C, err := ui.SharedCommitments()
+		if err != nil {
+			te.Logf("error : %+v", err)
+			te.Fail()
+			return
+		}
 		for _, uj := range users {
 			if ui.Idx() == uj.Idx() {
 				continue
@@ -85,11 +102,20 @@ func TestThreshold(te *testing.T) {
 	}
 	te.Logf("Signers : %+v", ts)
 	for _, user := range tusers {
-		user.SetSigners(ts)
+		if err := user.SetSigners(ts); err != nil {
+			te.Logf("error : %+v", err)
+			te.Fail()
+			return
+		}
 	}
 	te.Logf("Step1 / %fs", (time.Now().Sub(start)).Seconds())
 	for _, ui := range tusers {
-		C := ui.RandomCommitments()
+		C, err := ui.RandomCommitments()
+		if err != nil {
+			te.Logf("error : %+v", err)
+			te.Fail()
+			return
+		}
 		for _, uj := range tusers {
 			if ui.Idx() == uj.Idx() {
 				continue
@@ -134,7 +160,11 @@ func TestThreshold(te *testing.T) {
 	}
 	te.Logf("Step4 / %fs", (time.Now().Sub(start)).Seconds())
 	for _, ui := range tusers {
-		ui.SetMessage(m)
+		if err := ui.SetMessage(m); err != nil {
+			te.Logf("error : %+v", err)
+			te.Fail()
+			return
+		}
 	}
 	for _, ui := range tusers {
 		sig := ui.Signature()
@@ -152,14 +182,78 @@ func TestThreshold(te *testing.T) {
 	}
 	te.Logf("Step5 / %fs", (time.Now().Sub(start)).Seconds())
 	idx := rndi(len(tusers))
-	sig := tusers[idx].Signing()
-	P := tusers[idx].SharedPublickey()
+	sig, err := tusers[idx].Signing()
+	if err != nil {
+		te.Logf("error : %+v", err)
+		te.Fail()
+		return
+	}
+	P := tusers[idx].Q()
 	te.Logf("Verification / %f s", (time.Now().Sub(start)).Seconds())
+	// A tweaked signature is a BIP340 signature for the Taproot output key.
 	v := bipschnorr.Verification(P, m, sig)
+	if tweak {
+		v = bipschnorr.VerificationBIP340(P.BytesXOnly(), m, sig)
+	}
 	te.Logf("%d of %d threshold signature : %v / %f s", t, k, v, (time.Now().Sub(start)).Seconds())
 	if !v {
 		te.Logf("fail verify : %v", v)
 		te.Fail()
 		return
 	}
+	for _, user := range tusers {
+		user.Close()
+		if sig := user.Signature(); sig != nil {
+			te.Errorf("signed after Close : %v", sig)
+			return
+		}
+	}
+}
+
+func TestThresholdParameters(te *testing.T) {
+	H := bipschnorr.NewPoint(rndbi())
+	for _, v := range [][3]int{{3, 0, 1}, {3, 4, 1}, {3, 2, 0}, {3, 2, 4}} {
+		if _, err := bipschnorr.NewThresholdUser(v[0], v[1], v[2], H); err == nil {
+			te.Errorf("accepted k, t, i : %v", v)
+		}
+	}
+	if _, err := bipschnorr.NewThresholdUser(3, 2, 1, nil); err == nil {
+		te.Errorf("accepted nil H")
+	}
+	user, err := bipschnorr.NewThresholdUser(3, 2, 1, H)
+	if err != nil {
+		te.Fatalf("error : %+v", err)
+	}
+	C, err := user.SharedCommitments()
+	if err != nil {
+		te.Fatalf("error : %+v", err)
+	}
+	for _, v := range []struct {
+		j int
+		C []*bipschnorr.Point
+	}{{0, C}, {4, C}, {1, C}, {2, C[:1]}, {2, []*bipschnorr.Point{C[0], nil}}} {
+		if err := user.SetSharedCommitments(v.j, v.C); err == nil {
+			te.Errorf("accepted commitments from the user(%d) : %d points", v.j, len(v.C))
+		}
+	}
+	if err := user.SetSharedSecret(2, big.NewInt(1), big.NewInt(1), [][]*bipschnorr.Point{nil}); err == nil {
+		te.Errorf("accepted shared secret before commitments")
+	}
+	if s, sd := user.SharedSecret(4); s != nil || sd != nil {
+		te.Errorf("shared secret for the user(4)")
+	}
+	for _, ts := range [][]int{{1}, {1, 4}, {2, 2}, {1, 2, 3}} {
+		if err := user.SetSigners(ts); err == nil {
+			te.Errorf("accepted signers : %v", ts)
+		}
+	}
+	if err := user.SetMessage(nil); err == nil {
+		te.Errorf("accepted nil message")
+	}
+	if _, err := user.Signing(); err == nil {
+		te.Errorf("signed without signers")
+	}
+	if sig := user.Signature(); sig != nil {
+		te.Errorf("signature without signers : %v", sig)
+	}
 }
@@ -0,0 +1,114 @@
+package bipschnorr
+
+// Taproot key tweaking.
+// https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// TweakPublicKey returns the Taproot output key Q = P' + int(hash_TapTweak(bytes(P) || merkleRoot))G,
+// where P' is whichever of P and -P has an even Y coordinate and bytes(P) is the 32 byte X coordinate.
+// merkleRoot is the 32 byte root of the script tree, or empty for an output without scripts.
+// The output key is Q.BytesXOnly() and the parity of y(Q) goes into control blocks.
+func TweakPublicKey(P *Point, merkleRoot []byte) (*Point, error) {
+	Q, _, _, err := tapTweak(P, merkleRoot)
+	return Q, err
+}
+
+// TweakPrivateKey returns the secret key d' + t for the output key TweakPublicKey(dG, merkleRoot),
+// where d' is whichever of d and n - d gives an even Y coordinate. SigningBIP340 signs with it for the output key.
+// It fails if d' + t = 0 mod n, for which the output key is infinite.
+func TweakPrivateKey(d *big.Int, merkleRoot []byte) (*big.Int, error) {
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("private key out of range 1..n-1")
+	}
+	var sd, nd scalar
+	sd.setInt(d)
+	P := scalarBaseMul(&sd)
+	// Let d' = d if has_even_y(P), otherwise let d' = n - d.
+	sd.cmov(nd.neg(&sd), !hasEvenY(P))
+	t, err := tapTweakHash(P, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	// Fail if d' + t = 0 mod n.
+	if sd.add(&sd, &t).isZero() {
+		return nil, fmt.Errorf("tweaked key is infinite")
+	}
+	return sd.bigInt(), nil
+}
+
+// tapTweak returns Q = P' + tG, whether P' is -P, and t.
+func tapTweak(P *Point, merkleRoot []byte) (*Point, bool, *scalar, error) {
+	if !oncurve(P) {
+		return nil, false, nil, ErrPubKeyNotOnCurve
+	}
+	t, err := tapTweakHash(P, merkleRoot)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	neg := !hasEvenY(P)
+	var one scalar
+	one.setUint(1)
+	if neg {
+		one.neg(&one)
+	}
+	J := ecmult(&t, []ecmultTerm{{one, P.jacobian()}})
+	if J.isInfinity() {
+		return nil, false, nil, fmt.Errorf("tweaked key is infinite")
+	}
+	return J.point(), neg, &t, nil
+}
+
+// tapTweakEven is tapTweak for signing with BIP340, which signs for Q with an even Y coordinate:
+// when y(Q) is odd, whether P is negated is flipped and t is negated, so ±d + t is the secret key of the even Q.
+// Muser, Tuser and Coordinator sign a tweaked session this way, with the BIP340 challenge and verification,
+// so the signature is a BIP340 signature for Q.BytesXOnly().
+func tapTweakEven(P *Point, merkleRoot []byte) (*Point, bool, *scalar, error) {
+	Q, neg, t, err := tapTweak(P, merkleRoot)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if !hasEvenY(Q) {
+		neg = !neg
+		t.neg(t)
+	}
+	return Q, neg, t, nil
+}
+
+// challenge returns the challenge e for R, Q and m, and whether the nonce k is negated for R:
+// the BIP340 challenge with an even Y coordinate of R when signing for a Taproot output key,
+// the draft's challenge with jacobi(y(R)) = 1 otherwise.
+func challenge(R, Q *Point, m []byte, bip340 bool) (*scalar, bool) {
+	var e scalar
+	if bip340 {
+		// Let e = int(hash_BIP0340/challenge(bytes(R) || bytes(Q) || m)) mod n, and k = n - k if not has_even_y(R).
+		e.setBytes(hashTag("BIP0340/challenge", ll(R.BytesXOnly(), Q.BytesXOnly(), m)))
+		return &e, !hasEvenY(R)
+	}
+	// Let e = int(hash(bytes(x(R)) || bytes(Q) || m)) mod n, and k = n - k if jacobi(y(R)) ≠ 1.
+	e.setBytes(hash(ll(bytes(x(R)), Q.Bytes(), m)))
+	return &e, jacobi(y(R)).Cmp(big.NewInt(1)) != 0
+}
+
+// verification returns whether sig is a signature of m for Q, with VerificationBIP340 for a Taproot output key.
+func verification(Q *Point, m []byte, sig []byte, bip340 bool) bool {
+	if bip340 {
+		return VerificationBIP340(Q.BytesXOnly(), m, sig)
+	}
+	return Verification(Q, m, sig)
+}
+
+// tapTweakHash returns t = int(hash_TapTweak(bytes(P) || merkleRoot)); fails if t ≥ n.
+func tapTweakHash(P *Point, merkleRoot []byte) (scalar, error) {
+	var t scalar
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return t, fmt.Errorf("merkle root must be empty or 32 bytes, got %d", len(merkleRoot))
+	}
+	if !t.setBytes(hashTag("TapTweak", ll(P.BytesXOnly(), merkleRoot))) {
+		return t, fmt.Errorf("tweak not below n")
+	}
+	return t, nil
+}
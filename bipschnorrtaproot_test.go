@@ -0,0 +1,64 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"crypto/rand"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestTweakPublicKey(t *testing.T) {
+	// https://github.com/bitcoin/bips/blob/master/bip-0341/wallet-test-vectors.json scriptPubKey
+	for _, v := range []struct {
+		internalPubkey, merkleRoot, tweakedPubkey string
+	}{
+		{"d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d", "",
+			"53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343"},
+		{"187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27", "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+			"147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3"},
+	} {
+		pub, _ := hex.DecodeString(v.internalPubkey)
+		root, _ := hex.DecodeString(v.merkleRoot)
+		P, err := bipschnorr.ParsePoint(pub)
+		if err != nil {
+			t.Fatalf("ParsePoint : %v", err)
+		}
+		Q, err := bipschnorr.TweakPublicKey(P, root)
+		if err != nil {
+			t.Fatalf("TweakPublicKey : %v", err)
+		}
+		if hex.EncodeToString(Q.BytesXOnly()) != v.tweakedPubkey {
+			t.Errorf("no match TweakPublicKey : %x", Q.BytesXOnly())
+		}
+	}
+	if _, err := bipschnorr.TweakPublicKey(bipschnorr.G, make([]byte, 31)); err == nil {
+		t.Errorf("TweakPublicKey accepted a 31 byte merkle root")
+	}
+}
+
+func TestTweakPrivateKey(t *testing.T) {
+	for _, root := range [][]byte{nil, rndbs()} {
+		d := rndbi()
+		Q, err := bipschnorr.TweakPublicKey(bipschnorr.NewPoint(d), root)
+		if err != nil {
+			t.Fatalf("TweakPublicKey : %v", err)
+		}
+		q, err := bipschnorr.TweakPrivateKey(d, root)
+		if err != nil {
+			t.Fatalf("TweakPrivateKey : %v", err)
+		}
+		if !reflect.DeepEqual(bipschnorr.NewPoint(q), Q) {
+			t.Errorf("no match TweakPrivateKey")
+		}
+		// The output key signs with BIP340.
+		m := rndbs()
+		aux := make([]byte, 32)
+		rand.Read(aux)
+		sig := bipschnorr.SigningBIP340(q, m, aux)
+		if !bipschnorr.VerificationBIP340(Q.BytesXOnly(), m, sig) {
+			t.Errorf("fail VerificationBIP340")
+		}
+	}
+}
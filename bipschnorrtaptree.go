@@ -0,0 +1,134 @@
+package bipschnorr
+
+// Taproot script trees.
+// https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// TapLeafVersion is the leaf version of tapscript (BIP342).
+const TapLeafVersion = 0xc0
+
+// TapTree is a Taproot script tree, either a leaf script with its leaf version or a branch of two trees.
+type TapTree struct {
+	Version byte
+	Script  []byte
+	Left    *TapTree
+	Right   *TapTree
+}
+
+// NewTapLeaf returns the tree of a single leaf script.
+func NewTapLeaf(version byte, script []byte) *TapTree {
+	return &TapTree{Version: version, Script: script}
+}
+
+// NewTapBranch returns the tree with the subtrees left and right.
+func NewTapBranch(left, right *TapTree) *TapTree {
+	return &TapTree{Left: left, Right: right}
+}
+
+// TapLeafHash returns hash_TapLeaf(version || compact_size(size of script) || script).
+func TapLeafHash(version byte, script []byte) []byte {
+	return hashTag("TapLeaf", ll([]byte{version}, compactSize(uint64(len(script))), script))
+}
+
+// TapBranchHash returns hash_TapBranch(a || b) for the lexicographically smaller of a and b first.
+func TapBranchHash(a, b []byte) []byte {
+	if string(b) < string(a) {
+		a, b = b, a
+	}
+	return hashTag("TapBranch", ll(a, b))
+}
+
+// isLeaf returns whether t is a leaf.
+func (t *TapTree) isLeaf() bool {
+	return t.Left == nil && t.Right == nil
+}
+
+// Hash returns the leaf hash of a leaf and the branch hash of a branch, failing for a nil tree or a branch with a nil subtree.
+func (t *TapTree) Hash() ([]byte, error) {
+	_, _, h, err := t.paths()
+	return h, err
+}
+
+// MerkleRoot returns the merkle root for TweakPublicKey, which is empty for a nil tree (no script path).
+func (t *TapTree) MerkleRoot() ([]byte, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return t.Hash()
+}
+
+// Leaves returns the leaves of t from left to right.
+func (t *TapTree) Leaves() ([]*TapTree, error) {
+	leaves, _, _, err := t.paths()
+	return leaves, err
+}
+
+// ControlBlocks returns the control blocks for spending the leaves of t from left to right, with the internal key P:
+// byte(leaf version | parity of y(Q)) || bytes(P) || the hashes of the merkle path from the leaf up, where Q is the output key.
+func (t *TapTree) ControlBlocks(P *Point) ([][]byte, error) {
+	leaves, paths, root, err := t.paths()
+	if err != nil {
+		return nil, err
+	}
+	Q, err := TweakPublicKey(P, root)
+	if err != nil {
+		return nil, err
+	}
+	cbs := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		if leaf.Version&1 != 0 {
+			return nil, fmt.Errorf("invalid leaf version 0x%02x", leaf.Version)
+		}
+		cbs[i] = ll([]byte{leaf.Version | byte(y(Q).Bit(0))}, P.BytesXOnly(), paths[i])
+	}
+	return cbs, nil
+}
+
+// paths returns the leaves of t, the merkle path of each leaf and the hash of t.
+// It fails if t is nil, so a branch must have both subtrees.
+func (t *TapTree) paths() ([]*TapTree, [][]byte, []byte, error) {
+	if t == nil {
+		return nil, nil, nil, fmt.Errorf("empty script tree")
+	}
+	if t.isLeaf() {
+		return []*TapTree{t}, [][]byte{{}}, TapLeafHash(t.Version, t.Script), nil
+	}
+	left, lpaths, lh, err := t.Left.paths()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	right, rpaths, rh, err := t.Right.paths()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i := range lpaths {
+		lpaths[i] = ll(lpaths[i], rh)
+	}
+	for i := range rpaths {
+		rpaths[i] = ll(rpaths[i], lh)
+	}
+	return append(left, right...), append(lpaths, rpaths...), TapBranchHash(lh, rh), nil
+}
+
+// compactSize returns the Bitcoin variable length encoding of x.
+func compactSize(x uint64) []byte {
+	switch {
+	case x < 0xfd:
+		return []byte{byte(x)}
+	case x <= 0xffff:
+		bs := []byte{0xfd, 0, 0}
+		binary.LittleEndian.PutUint16(bs[1:], uint16(x))
+		return bs
+	case x <= 0xffffffff:
+		bs := []byte{0xfe, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(bs[1:], uint32(x))
+		return bs
+	}
+	bs := []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(bs[1:], x)
+	return bs
+}
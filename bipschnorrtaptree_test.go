@@ -0,0 +1,142 @@
+package bipschnorr_test
+
+import (
+	"github.com/tnakagawa/bipschnorr"
+
+	"encoding/hex"
+	"testing"
+)
+
+func TestTapTree(t *testing.T) {
+	// https://github.com/bitcoin/bips/blob/master/bip-0341/wallet-test-vectors.json scriptPubKey
+	leaf := func(version byte, script string) *bipschnorr.TapTree {
+		bs, _ := hex.DecodeString(script)
+		return bipschnorr.NewTapLeaf(version, bs)
+	}
+	branch := bipschnorr.NewTapBranch
+	for _, v := range []struct {
+		internalPubkey string
+		tree           *bipschnorr.TapTree
+		leafHashes     []string
+		merkleRoot     string
+		tweakedPubkey  string
+		controlBlocks  []string
+	}{
+		{"d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d",
+			nil,
+			nil,
+			"",
+			"53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343",
+			nil},
+		{"187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27",
+			leaf(192, "20d85a959b0290bf19bb89ed43c916be835475d013da4b362117393e25a48229b8ac"),
+			[]string{"5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21"},
+			"5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+			"147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3",
+			[]string{"c1187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27"}},
+		{"93478e9488f956df2396be2ce6c5cced75f900dfa18e7dabd2428aae78451820",
+			leaf(192, "20b617298552a72ade070667e86ca63b8f5789a9fe8731ef91202a91c9f3459007ac"),
+			[]string{"c525714a7f49c28aedbbba78c005931a81c234b2f6c99a73e4d06082adc8bf2b"},
+			"c525714a7f49c28aedbbba78c005931a81c234b2f6c99a73e4d06082adc8bf2b",
+			"e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e",
+			[]string{"c093478e9488f956df2396be2ce6c5cced75f900dfa18e7dabd2428aae78451820"}},
+		{"ee4fe085983462a184015d1f782d6a5f8b9c2b60130aff050ce221ecf3786592",
+			branch(leaf(192, "20387671353e273264c495656e27e39ba899ea8fee3bb69fb2a680e22093447d48ac"), leaf(250, "06424950333431")),
+			[]string{"8ad69ec7cf41c2a4001fd1f738bf1e505ce2277acdcaa63fe4765192497f47a7", "f224a923cd0021ab202ab139cc56802ddb92dcfc172b9212261a539df79a112a"},
+			"6c2dc106ab816b73f9d07e3cd1ef2c8c1256f519748e0813e4edd2405d277bef",
+			"712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5",
+			[]string{"c0ee4fe085983462a184015d1f782d6a5f8b9c2b60130aff050ce221ecf3786592f224a923cd0021ab202ab139cc56802ddb92dcfc172b9212261a539df79a112a",
+				"faee4fe085983462a184015d1f782d6a5f8b9c2b60130aff050ce221ecf37865928ad69ec7cf41c2a4001fd1f738bf1e505ce2277acdcaa63fe4765192497f47a7"}},
+		{"f9f400803e683727b14f463836e1e78e1c64417638aa066919291a225f0e8dd8",
+			branch(leaf(192, "2044b178d64c32c4a05cc4f4d1407268f764c940d20ce97abfd44db5c3592b72fdac"), leaf(192, "07546170726f6f74")),
+			[]string{"64512fecdb5afa04f98839b50e6f0cb7b1e539bf6f205f67934083cdcc3c8d89", "2cb2b90daa543b544161530c925f285b06196940d6085ca9474d41dc3822c5cb"},
+			"ab179431c28d3b68fb798957faf5497d69c883c6fb1e1cd9f81483d87bac90cc",
+			"77e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220",
+			[]string{"c1f9f400803e683727b14f463836e1e78e1c64417638aa066919291a225f0e8dd82cb2b90daa543b544161530c925f285b06196940d6085ca9474d41dc3822c5cb",
+				"c1f9f400803e683727b14f463836e1e78e1c64417638aa066919291a225f0e8dd864512fecdb5afa04f98839b50e6f0cb7b1e539bf6f205f67934083cdcc3c8d89"}},
+		{"e0dfe2300b0dd746a3f8674dfd4525623639042569d829c7f0eed9602d263e6f",
+			branch(leaf(192, "2072ea6adcf1d371dea8fba1035a09f3d24ed5a059799bae114084130ee5898e69ac"),
+				branch(leaf(192, "202352d137f2f3ab38d1eaa976758873377fa5ebb817372c71e2c542313d4abda8ac"), leaf(192, "207337c0dd4253cb86f2c43a2351aadd82cccb12a172cd120452b9bb8324f2186aac"))),
+			[]string{"2645a02e0aac1fe69d69755733a9b7621b694bb5b5cde2bbfc94066ed62b9817", "ba982a91d4fc552163cb1c0da03676102d5b7a014304c01f0c77b2b8e888de1c", "9e31407bffa15fefbf5090b149d53959ecdf3f62b1246780238c24501d5ceaf6"},
+			"ccbd66c6f7e8fdab47b3a486f59d28262be857f30d4773f2d5ea47f7761ce0e2",
+			"91b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605",
+			[]string{"c0e0dfe2300b0dd746a3f8674dfd4525623639042569d829c7f0eed9602d263e6fffe578e9ea769027e4f5a3de40732f75a88a6353a09d767ddeb66accef85e553",
+				"c0e0dfe2300b0dd746a3f8674dfd4525623639042569d829c7f0eed9602d263e6f9e31407bffa15fefbf5090b149d53959ecdf3f62b1246780238c24501d5ceaf62645a02e0aac1fe69d69755733a9b7621b694bb5b5cde2bbfc94066ed62b9817",
+				"c0e0dfe2300b0dd746a3f8674dfd4525623639042569d829c7f0eed9602d263e6fba982a91d4fc552163cb1c0da03676102d5b7a014304c01f0c77b2b8e888de1c2645a02e0aac1fe69d69755733a9b7621b694bb5b5cde2bbfc94066ed62b9817"}},
+		{"55adf4e8967fbd2e29f20ac896e60c3b0f1d5b0efa9d34941b5958c7b0a0312d",
+			branch(leaf(192, "2071981521ad9fc9036687364118fb6ccd2035b96a423c59c5430e98310a11abe2ac"),
+				branch(leaf(192, "20d5094d2dbe9b76e2c245a2b89b6006888952e2faa6a149ae318d69e520617748ac"), leaf(192, "20c440b462ad48c7a77f94cd4532d8f2119dcebbd7c9764557e62726419b08ad4cac"))),
+			[]string{"f154e8e8e17c31d3462d7132589ed29353c6fafdb884c5a6e04ea938834f0d9d", "737ed1fe30bc42b8022d717b44f0d93516617af64a64753b7a06bf16b26cd711", "d7485025fceb78b9ed667db36ed8b8dc7b1f0b307ac167fa516fe4352b9f4ef7"},
+			"2f6b2c5397b6d68ca18e09a3f05161668ffe93a988582d55c6f07bd5b3329def",
+			"75169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831",
+			[]string{"c155adf4e8967fbd2e29f20ac896e60c3b0f1d5b0efa9d34941b5958c7b0a0312d3cd369a528b326bc9d2133cbd2ac21451acb31681a410434672c8e34fe757e91",
+				"c155adf4e8967fbd2e29f20ac896e60c3b0f1d5b0efa9d34941b5958c7b0a0312dd7485025fceb78b9ed667db36ed8b8dc7b1f0b307ac167fa516fe4352b9f4ef7f154e8e8e17c31d3462d7132589ed29353c6fafdb884c5a6e04ea938834f0d9d",
+				"c155adf4e8967fbd2e29f20ac896e60c3b0f1d5b0efa9d34941b5958c7b0a0312d737ed1fe30bc42b8022d717b44f0d93516617af64a64753b7a06bf16b26cd711f154e8e8e17c31d3462d7132589ed29353c6fafdb884c5a6e04ea938834f0d9d"}},
+	} {
+		pub, _ := hex.DecodeString(v.internalPubkey)
+		P, _ := bipschnorr.ParsePoint(pub)
+		root, err := v.tree.MerkleRoot()
+		if err != nil {
+			t.Fatalf("MerkleRoot : %v", err)
+		}
+		if h := hex.EncodeToString(root); h != v.merkleRoot {
+			t.Errorf("no match merkle root : %s", h)
+		}
+		Q, err := bipschnorr.TweakPublicKey(P, root)
+		if err != nil {
+			t.Fatalf("TweakPublicKey : %v", err)
+		}
+		if h := hex.EncodeToString(Q.BytesXOnly()); h != v.tweakedPubkey {
+			t.Errorf("no match tweaked public key : %s", h)
+		}
+		if v.tree == nil {
+			// A key path only output has no leaves to spend.
+			if _, err := v.tree.ControlBlocks(P); err == nil {
+				t.Errorf("ControlBlocks of a nil tree")
+			}
+			continue
+		}
+		leaves, err := v.tree.Leaves()
+		if err != nil {
+			t.Fatalf("Leaves : %v", err)
+		}
+		for i, leaf := range leaves {
+			h, _ := leaf.Hash()
+			if hex.EncodeToString(h) != v.leafHashes[i] {
+				t.Errorf("no match leaf hash %d : %x", i, h)
+			}
+		}
+		cbs, err := v.tree.ControlBlocks(P)
+		if err != nil {
+			t.Fatalf("ControlBlocks : %v", err)
+		}
+		if len(cbs) != len(v.controlBlocks) {
+			t.Fatalf("no match number of control blocks : %d", len(cbs))
+		}
+		for i, cb := range cbs {
+			if h := hex.EncodeToString(cb); h != v.controlBlocks[i] {
+				t.Errorf("no match control block %d : %s", i, h)
+			}
+		}
+	}
+
+	if _, err := bipschnorr.NewTapLeaf(0xc1, nil).ControlBlocks(bipschnorr.G); err == nil {
+		t.Errorf("ControlBlocks accepted an odd leaf version")
+	}
+	// A branch with a missing subtree fails instead of dereferencing nil.
+	half := bipschnorr.NewTapBranch(bipschnorr.NewTapLeaf(0xc0, nil), nil)
+	for _, tree := range []*bipschnorr.TapTree{half, bipschnorr.NewTapBranch(nil, half)} {
+		if _, err := tree.Hash(); err == nil {
+			t.Errorf("Hash accepted a branch with a nil subtree")
+		}
+		if _, err := tree.MerkleRoot(); err == nil {
+			t.Errorf("MerkleRoot accepted a branch with a nil subtree")
+		}
+		if _, err := tree.Leaves(); err == nil {
+			t.Errorf("Leaves accepted a branch with a nil subtree")
+		}
+		if _, err := tree.ControlBlocks(bipschnorr.G); err == nil {
+			t.Errorf("ControlBlocks accepted a branch with a nil subtree")
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package bipschnorr
+
+// NewSecNonce returns the secret nonce of a 97 byte BIP327 secnonce, for the test vectors only.
+func NewSecNonce(secnonce []byte) *SecNonce {
+	sn := &SecNonce{pk: append([]byte{}, secnonce[64:]...)}
+	sn.k1.setBytes(secnonce[:32])
+	sn.k2.setBytes(secnonce[32:64])
+	return sn
+}